@@ -0,0 +1,157 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"go.uber.org/zap"
+)
+
+// ReadItem is a range of a table in a device to read as part of a batch.
+type ReadItem struct {
+	Address uint16
+	Table   data.Table
+	Offset  uint16
+	Count   uint16
+}
+
+// ReadResult is what was read for a ReadItem. Bits is set for coils and discrete inputs, Registers for holding and input
+// registers, and Err when the request the item was read with failed.
+type ReadResult struct {
+	Bits      []bool
+	Registers []uint16
+	Err       error
+}
+
+// BatchOptions controls how the items of a batch are combined into requests.
+type BatchOptions struct {
+	// MaxGap is the most unwanted coils or registers between two items that are still read with one request. Reading a
+	// few extra registers is usually much quicker than another round trip, especially on slow serial lines.
+	MaxGap int
+}
+
+// plannedRead is a single range read for one or more items of a batch
+type plannedRead struct {
+	address uint16
+	table   data.Table
+	offset  uint16
+	count   int
+	items   []int
+}
+
+// planReads merges the items for the same table in the same device that are no more than maxGap apart into as few
+// reads as it can, without any read going over the block size for the device
+func planReads(items []ReadItem, maxGap int, limits func(address uint16) RequestLimits) ([]plannedRead, error) {
+	order := make([]int, len(items))
+	for i, item := range items {
+		if item.Table > data.InputRegisters {
+			return nil, common.ErrInvalidValue
+		}
+		if item.Count == 0 || int(item.Offset)+int(item.Count) > 0x10000 {
+			return nil, common.ErrInvalidCount
+		}
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		x, y := items[a], items[b]
+		return cmp.Or(cmp.Compare(x.Address, y.Address), cmp.Compare(x.Table, y.Table), cmp.Compare(x.Offset, y.Offset))
+	})
+
+	var plan []plannedRead
+	for _, i := range order {
+		item := items[i]
+		end := int(item.Offset) + int(item.Count)
+		if len(plan) > 0 {
+			last := &plan[len(plan)-1]
+			lastEnd := int(last.offset) + last.count
+			if last.address == item.Address && last.table == item.Table && int(item.Offset) <= lastEnd+maxGap &&
+				max(end, lastEnd)-int(last.offset) <= blockSize(item.Table, limits(item.Address)) {
+				last.count = max(end, lastEnd) - int(last.offset)
+				last.items = append(last.items, i)
+				continue
+			}
+		}
+		plan = append(plan, plannedRead{
+			address: item.Address,
+			table:   item.Table,
+			offset:  item.Offset,
+			count:   int(item.Count),
+			items:   []int{i},
+		})
+	}
+	return plan, nil
+}
+
+func blockSize(table data.Table, limits RequestLimits) int {
+	if table.IsBits() {
+		return int(limit(limits.ReadBits, MaxReadBits))
+	}
+	return int(limit(limits.ReadRegisters, MaxReadRegisters))
+}
+
+func (m *modbusClient) ReadBatch(items []ReadItem, options BatchOptions) ([]ReadResult, error) {
+	return m.ReadBatchContext(context.Background(), items, options)
+}
+
+func (m *modbusClient) ReadBatchContext(ctx context.Context, items []ReadItem, options BatchOptions) ([]ReadResult, error) {
+	plan, err := planReads(items, options.MaxGap, m.requestLimits)
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Planned batch read", zap.Int("items", len(items)), zap.Int("requests", len(plan)))
+	results := make([]ReadResult, len(items))
+	var errs []error
+	for _, read := range plan {
+		err := m.executeRead(ctx, items, results, read)
+		// The gap may cover addresses the device doesn't have, in which case the items are read on their own instead
+		if errors.Is(err, common.ErrIllegalDataAddress) && len(read.items) > 1 {
+			m.logger.Debug("Batch read rejected, reading its items separately", zap.Uint16("address", read.address), zap.Stringer("table", read.table), zap.Uint16("offset", read.offset))
+			err = nil
+			for _, i := range read.items {
+				single := plannedRead{address: items[i].Address, table: items[i].Table, offset: items[i].Offset, count: int(items[i].Count), items: []int{i}}
+				if itemErr := m.executeRead(ctx, items, results, single); itemErr != nil {
+					err = errors.Join(err, itemErr)
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// executeRead reads a planned range and hands each of its items its part
+func (m *modbusClient) executeRead(ctx context.Context, items []ReadItem, results []ReadResult, read plannedRead) error {
+	var bits []bool
+	var registers []uint16
+	var err error
+	switch read.table {
+	case data.Coils:
+		bits, err = m.ReadCoilsContext(ctx, read.address, read.offset, uint16(read.count))
+	case data.DiscreteInputs:
+		bits, err = m.ReadDiscreteInputsContext(ctx, read.address, read.offset, uint16(read.count))
+	case data.HoldingRegisters:
+		registers, err = m.ReadHoldingRegistersContext(ctx, read.address, read.offset, uint16(read.count))
+	case data.InputRegisters:
+		registers, err = m.ReadInputRegistersContext(ctx, read.address, read.offset, uint16(read.count))
+	}
+	for _, i := range read.items {
+		if err != nil {
+			results[i] = ReadResult{Err: err}
+			continue
+		}
+		start := int(items[i].Offset - read.offset)
+		end := start + int(items[i].Count)
+		if read.table.IsBits() {
+			results[i] = ReadResult{Bits: slices.Clone(bits[start:end])}
+		} else {
+			results[i] = ReadResult{Registers: slices.Clone(registers[start:end])}
+		}
+	}
+	return err
+}
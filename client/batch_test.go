@@ -0,0 +1,116 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReads(t *testing.T) {
+	noLimits := func(uint16) RequestLimits { return RequestLimits{} }
+	tests := []struct {
+		name   string
+		items  []ReadItem
+		maxGap int
+		limits func(uint16) RequestLimits
+		plan   []plannedRead
+		err    error
+	}{
+		{
+			name: "Adjacent and overlapping",
+			items: []ReadItem{
+				{Address: 1, Table: data.HoldingRegisters, Offset: 10, Count: 2},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 0, Count: 10},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 5, Count: 2},
+			},
+			limits: noLimits,
+			plan: []plannedRead{
+				{address: 1, table: data.HoldingRegisters, offset: 0, count: 12, items: []int{1, 2, 0}},
+			},
+		},
+		{
+			name: "Gap tolerance",
+			items: []ReadItem{
+				{Address: 1, Table: data.HoldingRegisters, Offset: 0, Count: 2},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 7, Count: 1},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 20, Count: 1},
+			},
+			maxGap: 5,
+			limits: noLimits,
+			plan: []plannedRead{
+				{address: 1, table: data.HoldingRegisters, offset: 0, count: 8, items: []int{0, 1}},
+				{address: 1, table: data.HoldingRegisters, offset: 20, count: 1, items: []int{2}},
+			},
+		},
+		{
+			name: "Devices and tables are read separately",
+			items: []ReadItem{
+				{Address: 2, Table: data.HoldingRegisters, Offset: 0, Count: 1},
+				{Address: 1, Table: data.InputRegisters, Offset: 1, Count: 1},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 1, Count: 1},
+				{Address: 1, Table: data.Coils, Offset: 1, Count: 1},
+			},
+			maxGap: 10,
+			limits: noLimits,
+			plan: []plannedRead{
+				{address: 1, table: data.Coils, offset: 1, count: 1, items: []int{3}},
+				{address: 1, table: data.HoldingRegisters, offset: 1, count: 1, items: []int{2}},
+				{address: 1, table: data.InputRegisters, offset: 1, count: 1, items: []int{1}},
+				{address: 2, table: data.HoldingRegisters, offset: 0, count: 1, items: []int{0}},
+			},
+		},
+		{
+			name: "Block size",
+			items: []ReadItem{
+				{Address: 1, Table: data.HoldingRegisters, Offset: 0, Count: 10},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 10, Count: 10},
+				{Address: 1, Table: data.HoldingRegisters, Offset: 20, Count: 10},
+				{Address: 2, Table: data.HoldingRegisters, Offset: 0, Count: 10},
+				{Address: 2, Table: data.HoldingRegisters, Offset: 10, Count: 10},
+				{Address: 2, Table: data.HoldingRegisters, Offset: 20, Count: 10},
+			},
+			limits: func(address uint16) RequestLimits {
+				if address == 1 {
+					return RequestLimits{ReadRegisters: 20}
+				}
+				return RequestLimits{}
+			},
+			plan: []plannedRead{
+				{address: 1, table: data.HoldingRegisters, offset: 0, count: 20, items: []int{0, 1}},
+				{address: 1, table: data.HoldingRegisters, offset: 20, count: 10, items: []int{2}},
+				{address: 2, table: data.HoldingRegisters, offset: 0, count: 30, items: []int{3, 4, 5}},
+			},
+		},
+		{
+			name:   "Zero count",
+			items:  []ReadItem{{Address: 1, Table: data.HoldingRegisters, Offset: 0, Count: 0}},
+			limits: noLimits,
+			err:    common.ErrInvalidCount,
+		},
+		{
+			name:   "Past the end of the table",
+			items:  []ReadItem{{Address: 1, Table: data.Coils, Offset: 0xFFFF, Count: 2}},
+			limits: noLimits,
+			err:    common.ErrInvalidCount,
+		},
+		{
+			name:   "Unknown table",
+			items:  []ReadItem{{Address: 1, Table: data.Table(7), Offset: 0, Count: 1}},
+			limits: noLimits,
+			err:    common.ErrInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planReads(tt.items, tt.maxGap, tt.limits)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.plan, plan)
+		})
+	}
+}
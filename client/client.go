@@ -2,60 +2,242 @@ package client
 
 import (
 	"context"
+	"errors"
 	"io"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/rinzlerlabs/gomodbus/common"
 	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/transport"
+	"github.com/rinzlerlabs/gomodbus/transport/serial"
 	"go.uber.org/zap"
 )
 
-// ModbusClient defines the interface for a Modbus client.
+// ModbusClient defines the interface for a Modbus client. Every function has a variant ending in Context, which gives up
+// with the context's error when ctx is cancelled or its deadline passes, whether it is waiting for an earlier call to
+// finish or for the response.
 type ModbusClient interface {
 	io.Closer
 	// ReadCoils reads the status of coils in a remote device.
 	ReadCoils(address, offset, quantity uint16) ([]bool, error)
+	ReadCoilsContext(ctx context.Context, address, offset, quantity uint16) ([]bool, error)
 	// ReadDiscreteInputs reads the status of discrete inputs in a remote device.
 	ReadDiscreteInputs(address, offset, quantity uint16) ([]bool, error)
+	ReadDiscreteInputsContext(ctx context.Context, address, offset, quantity uint16) ([]bool, error)
 	// ReadHoldingRegisters reads the contents of holding registers in a remote device.
 	ReadHoldingRegisters(address, offset, quantity uint16) ([]uint16, error)
+	ReadHoldingRegistersContext(ctx context.Context, address, offset, quantity uint16) ([]uint16, error)
 	// ReadInputRegisters reads the contents of input registers in a remote device.
 	ReadInputRegisters(address, offset, quantity uint16) ([]uint16, error)
+	ReadInputRegistersContext(ctx context.Context, address, offset, quantity uint16) ([]uint16, error)
 	// WriteSingleCoil writes a single coil in a remote device.
 	WriteSingleCoil(address, offset uint16, value bool) error
+	WriteSingleCoilContext(ctx context.Context, address, offset uint16, value bool) error
 	// WriteSingleRegister writes a single holding register in a remote device.
 	WriteSingleRegister(address, offset, value uint16) error
+	WriteSingleRegisterContext(ctx context.Context, address, offset, value uint16) error
 	// WriteMultipleCoils writes multiple coils in a remote device.
 	WriteMultipleCoils(address, offset uint16, values []bool) error
+	WriteMultipleCoilsContext(ctx context.Context, address, offset uint16, values []bool) error
 	// WriteMultipleRegisters writes multiple holding registers in a remote device.
 	WriteMultipleRegisters(address, offset uint16, values []uint16) error
+	WriteMultipleRegistersContext(ctx context.Context, address, offset uint16, values []uint16) error
+	// MaskWriteRegister modifies a single holding register in a remote device using a combination of an AND mask and an OR mask.
+	MaskWriteRegister(address, offset, andMask, orMask uint16) error
+	MaskWriteRegisterContext(ctx context.Context, address, offset, andMask, orMask uint16) error
+	// ReadWriteMultipleRegisters writes multiple holding registers and then reads multiple holding registers in a remote device as a single transaction.
+	ReadWriteMultipleRegisters(address, readOffset, readQuantity, writeOffset uint16, values []uint16) ([]uint16, error)
+	ReadWriteMultipleRegistersContext(ctx context.Context, address, readOffset, readQuantity, writeOffset uint16, values []uint16) ([]uint16, error)
+	// ReadDeviceIdentification reads the identification objects of a remote device starting at objectID, following any continuations.
+	ReadDeviceIdentification(address uint16, code data.DeviceIDCode, objectID data.DeviceObjectID) (map[data.DeviceObjectID]string, error)
+	ReadDeviceIdentificationContext(ctx context.Context, address uint16, code data.DeviceIDCode, objectID data.DeviceObjectID) (map[data.DeviceObjectID]string, error)
+	// Diagnostics runs a serial line diagnostic sub-function in a remote device and returns the data word from the response.
+	// ForceListenOnlyMode is never answered, so it returns as soon as the request is sent.
+	Diagnostics(address uint16, subFunction data.DiagnosticSubFunction, value uint16) (uint16, error)
+	DiagnosticsContext(ctx context.Context, address uint16, subFunction data.DiagnosticSubFunction, value uint16) (uint16, error)
+	// ReadExceptionStatus reads the 8 exception status bits of a remote device.
+	ReadExceptionStatus(address uint16) (byte, error)
+	ReadExceptionStatusContext(ctx context.Context, address uint16) (byte, error)
+	// GetCommEventCounter reads the status word and comm event counter of a remote device.
+	GetCommEventCounter(address uint16) (status, eventCount uint16, err error)
+	GetCommEventCounterContext(ctx context.Context, address uint16) (status, eventCount uint16, err error)
+	// GetCommEventLog reads the status word, comm event counter, message count and event log of a remote device.
+	GetCommEventLog(address uint16) (data.ModbusGetCommEventLogResponse, error)
+	GetCommEventLogContext(ctx context.Context, address uint16) (data.ModbusGetCommEventLogResponse, error)
+	// ReportServerID reads the server ID and run indicator status of a remote device.
+	ReportServerID(address uint16) (serverID []byte, runIndicator bool, err error)
+	ReportServerIDContext(ctx context.Context, address uint16) (serverID []byte, runIndicator bool, err error)
+	// ReadFIFOQueue reads the contents of a FIFO queue of holding registers in a remote device, the queue is not cleared by reading it.
+	ReadFIFOQueue(address, pointer uint16) ([]uint16, error)
+	ReadFIFOQueueContext(ctx context.Context, address, pointer uint16) ([]uint16, error)
+	// ReadFileRecord reads groups of records from the files in a remote device, the values are returned in the order they were requested.
+	ReadFileRecord(address uint16, subRequests []data.FileSubRequest) ([][]uint16, error)
+	ReadFileRecordContext(ctx context.Context, address uint16, subRequests []data.FileSubRequest) ([][]uint16, error)
+	// WriteFileRecord writes groups of records to the files in a remote device.
+	WriteFileRecord(address uint16, records []data.FileRecord) error
+	WriteFileRecordContext(ctx context.Context, address uint16, records []data.FileRecord) error
+	// CustomFunction sends a request for a function code registered with data.RegisterCustomFunction and returns the response.
+	CustomFunction(address uint16, request data.ModbusCustomOperation) (data.ModbusCustomOperation, error)
+	CustomFunctionContext(ctx context.Context, address uint16, request data.ModbusCustomOperation) (data.ModbusCustomOperation, error)
+	// SetRequestLimits changes the most items a single request to a device reads or writes. Reads and writes of more
+	// items are split into several requests, so they are no longer atomic, and a write that fails partway leaves the
+	// earlier blocks written. Devices without limits of their own use the spec's.
+	SetRequestLimits(address uint16, limits RequestLimits)
+	// ReadBatch reads many ranges, from any number of devices and tables, with as few requests as it can by merging the
+	// ranges that are close together. The results are in the same order as the items. When a request fails its items
+	// get its error, the other items are still read, and the errors of every failed request are returned together.
+	ReadBatch(items []ReadItem, options BatchOptions) ([]ReadResult, error)
+	ReadBatchContext(ctx context.Context, items []ReadItem, options BatchOptions) ([]ReadResult, error)
 }
 
 // NewModbusClient creates a new Modbus client.
-func NewModbusClient(ctx context.Context, logger *zap.Logger, transport transport.Transport) ModbusClient {
+func NewModbusClient(ctx context.Context, logger *zap.Logger, t transport.Transport) ModbusClient {
+	return NewModbusClientWithRetryPolicy(ctx, logger, t, RetryPolicy{})
+}
+
+// NewModbusClientWithRetryPolicy creates a new Modbus client that makes failed calls again as the policy allows.
+func NewModbusClientWithRetryPolicy(ctx context.Context, logger *zap.Logger, t transport.Transport, policy RetryPolicy) ModbusClient {
+	// Requests are sent one at a time, unless the transport can match up the responses itself
+	inFlight := 1
+	if p, ok := t.(transport.PipelinedTransport); ok && p.MaxInFlight() > 1 {
+		inFlight = p.MaxInFlight()
+	}
 	return &modbusClient{
 		logger:    logger,
-		transport: transport,
+		transport: t,
 		ctx:       ctx,
+		sem:       make(chan struct{}, inFlight),
+		retry:     policy,
+		limits:    make(map[uint16]RequestLimits),
 	}
 }
 
 type modbusClient struct {
 	logger    *zap.Logger
 	transport transport.Transport
-	mu        sync.Mutex
-	ctx       context.Context
+	// sem limits the number of requests in flight, it is a channel rather than a mutex so waiting for it can be cancelled
+	sem   chan struct{}
+	ctx   context.Context
+	retry RetryPolicy
+	// limits is the request limits of each device that has them
+	limits   map[uint16]RequestLimits
+	limitsMu sync.RWMutex
+}
+
+// lock waits for the client to be free, giving up when ctx is done
+func (m *modbusClient) lock(ctx context.Context) error {
+	select {
+	case m.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *modbusClient) sendRequestAndReadResponse(address uint16, req *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *modbusClient) unlock() {
+	<-m.sem
+}
+
+// callContext ties the context of a single call to the lifetime of the client
+func (m *modbusClient) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(m.ctx, func() {
+		cancel(context.Cause(m.ctx))
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+func (m *modbusClient) sendRequestAndReadResponse(ctx context.Context, address uint16, req *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
+	if m.isBroadcast(address) {
+		// Nobody answers a broadcast, so only writes can be broadcast
+		return nil, common.ErrBroadcastNotSupported
+	}
+	ctx, cancel := m.callContext(ctx)
+	defer cancel()
+	backoff := m.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		adu, err := m.roundTrip(ctx, address, req)
+		if err == nil || !m.retry.shouldRetry(req.FunctionCode(), attempt, err) {
+			return adu, err
+		}
+		m.logger.Debug("Retrying request", zap.Uint16("address", address), zap.Int("attempt", attempt), zap.Error(err))
+		// The client is free for other calls while we wait
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if errors.Is(err, common.ErrTimeout) || errors.Is(err, common.ErrInvalidChecksum) {
+			// On a serial line the rest of the response we gave up on would be read as the start of the next one
+			m.flush(ctx)
+		}
+	}
+}
+
+func (m *modbusClient) roundTrip(ctx context.Context, address uint16, req *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.unlock()
 	adu, err := m.transport.WriteRequestFrame(address, req)
 	if err != nil {
 		return nil, err
 	}
-	return m.transport.ReadResponse(m.ctx, adu)
+	return m.transport.ReadResponse(ctx, adu)
+}
+
+// flush drops anything left in the transport by a response that wasn't read in full, network transports don't need to
+func (m *modbusClient) flush(ctx context.Context) {
+	if err := m.lock(ctx); err != nil {
+		return
+	}
+	defer m.unlock()
+	if err := m.transport.Flush(ctx); err != nil {
+		m.logger.Debug("Failed to flush transport", zap.Error(err))
+	}
+}
+
+func (m *modbusClient) sendRequest(ctx context.Context, address uint16, req *transport.ProtocolDataUnit) error {
+	ctx, cancel := m.callContext(ctx)
+	defer cancel()
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+	_, err := m.transport.WriteRequestFrame(address, req)
+	return err
+}
+
+// isBroadcast reports whether a request to address is carried out by every device without an answer
+func (m *modbusClient) isBroadcast(address uint16) bool {
+	_, ok := m.transport.(transport.BroadcastTransport)
+	return ok && address == serial.BroadcastAddress
+}
+
+// broadcast sends a request to every device, then keeps the bus for the turnaround delay so the devices have time to
+// carry it out before the next request
+func (m *modbusClient) broadcast(ctx context.Context, req *transport.ProtocolDataUnit) error {
+	ctx, cancel := m.callContext(ctx)
+	defer cancel()
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+	if _, err := m.transport.WriteRequestFrame(serial.BroadcastAddress, req); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(m.transport.(transport.BroadcastTransport).TurnaroundDelay()):
+		return nil
+	}
 }
 
 func (m *modbusClient) Close() error {
@@ -63,8 +245,19 @@ func (m *modbusClient) Close() error {
 }
 
 func (m *modbusClient) ReadCoils(address, offset, quantity uint16) ([]bool, error) {
+	return m.ReadCoilsContext(context.Background(), address, offset, quantity)
+}
+
+func (m *modbusClient) ReadCoilsContext(ctx context.Context, address, offset, quantity uint16) ([]bool, error) {
+	blockSize := limit(m.requestLimits(address).ReadBits, MaxReadBits)
+	return readInBlocks(ctx, offset, quantity, blockSize, func(ctx context.Context, offset, quantity uint16) ([]bool, error) {
+		return m.readCoils(ctx, address, offset, quantity)
+	})
+}
+
+func (m *modbusClient) readCoils(ctx context.Context, address, offset, quantity uint16) ([]bool, error) {
 	req := data.NewReadCoilsRequest(offset, quantity)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return nil, err
 	}
@@ -78,8 +271,19 @@ func (m *modbusClient) ReadCoils(address, offset, quantity uint16) ([]bool, erro
 }
 
 func (m *modbusClient) ReadDiscreteInputs(address, offset, quantity uint16) ([]bool, error) {
+	return m.ReadDiscreteInputsContext(context.Background(), address, offset, quantity)
+}
+
+func (m *modbusClient) ReadDiscreteInputsContext(ctx context.Context, address, offset, quantity uint16) ([]bool, error) {
+	blockSize := limit(m.requestLimits(address).ReadBits, MaxReadBits)
+	return readInBlocks(ctx, offset, quantity, blockSize, func(ctx context.Context, offset, quantity uint16) ([]bool, error) {
+		return m.readDiscreteInputs(ctx, address, offset, quantity)
+	})
+}
+
+func (m *modbusClient) readDiscreteInputs(ctx context.Context, address, offset, quantity uint16) ([]bool, error) {
 	req := data.NewReadDiscreteInputsRequest(offset, quantity)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +297,19 @@ func (m *modbusClient) ReadDiscreteInputs(address, offset, quantity uint16) ([]b
 }
 
 func (m *modbusClient) ReadHoldingRegisters(address, offset, quantity uint16) ([]uint16, error) {
+	return m.ReadHoldingRegistersContext(context.Background(), address, offset, quantity)
+}
+
+func (m *modbusClient) ReadHoldingRegistersContext(ctx context.Context, address, offset, quantity uint16) ([]uint16, error) {
+	blockSize := limit(m.requestLimits(address).ReadRegisters, MaxReadRegisters)
+	return readInBlocks(ctx, offset, quantity, blockSize, func(ctx context.Context, offset, quantity uint16) ([]uint16, error) {
+		return m.readHoldingRegisters(ctx, address, offset, quantity)
+	})
+}
+
+func (m *modbusClient) readHoldingRegisters(ctx context.Context, address, offset, quantity uint16) ([]uint16, error) {
 	req := data.NewReadHoldingRegistersRequest(offset, quantity)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +322,19 @@ func (m *modbusClient) ReadHoldingRegisters(address, offset, quantity uint16) ([
 }
 
 func (m *modbusClient) ReadInputRegisters(address, offset, quantity uint16) ([]uint16, error) {
+	return m.ReadInputRegistersContext(context.Background(), address, offset, quantity)
+}
+
+func (m *modbusClient) ReadInputRegistersContext(ctx context.Context, address, offset, quantity uint16) ([]uint16, error) {
+	blockSize := limit(m.requestLimits(address).ReadRegisters, MaxReadRegisters)
+	return readInBlocks(ctx, offset, quantity, blockSize, func(ctx context.Context, offset, quantity uint16) ([]uint16, error) {
+		return m.readInputRegisters(ctx, address, offset, quantity)
+	})
+}
+
+func (m *modbusClient) readInputRegisters(ctx context.Context, address, offset, quantity uint16) ([]uint16, error) {
 	req := data.NewReadInputRegistersRequest(offset, quantity)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return nil, err
 	}
@@ -121,8 +347,15 @@ func (m *modbusClient) ReadInputRegisters(address, offset, quantity uint16) ([]u
 }
 
 func (m *modbusClient) WriteSingleCoil(address, offset uint16, value bool) error {
+	return m.WriteSingleCoilContext(context.Background(), address, offset, value)
+}
+
+func (m *modbusClient) WriteSingleCoilContext(ctx context.Context, address, offset uint16, value bool) error {
 	req := data.NewWriteSingleCoilRequest(offset, value)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return err
 	}
@@ -141,8 +374,15 @@ func (m *modbusClient) WriteSingleCoil(address, offset uint16, value bool) error
 }
 
 func (m *modbusClient) WriteSingleRegister(address, offset, value uint16) error {
+	return m.WriteSingleRegisterContext(context.Background(), address, offset, value)
+}
+
+func (m *modbusClient) WriteSingleRegisterContext(ctx context.Context, address, offset, value uint16) error {
 	req := data.NewWriteSingleRegisterRequest(offset, value)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return err
 	}
@@ -161,8 +401,22 @@ func (m *modbusClient) WriteSingleRegister(address, offset, value uint16) error
 }
 
 func (m *modbusClient) WriteMultipleCoils(address, offset uint16, values []bool) error {
+	return m.WriteMultipleCoilsContext(context.Background(), address, offset, values)
+}
+
+func (m *modbusClient) WriteMultipleCoilsContext(ctx context.Context, address, offset uint16, values []bool) error {
+	blockSize := limit(m.requestLimits(address).WriteBits, MaxWriteBits)
+	return writeInBlocks(ctx, offset, values, blockSize, func(ctx context.Context, offset uint16, values []bool) error {
+		return m.writeMultipleCoils(ctx, address, offset, values)
+	})
+}
+
+func (m *modbusClient) writeMultipleCoils(ctx context.Context, address, offset uint16, values []bool) error {
 	req := data.NewWriteMultipleCoilsRequest(offset, values)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return err
 	}
@@ -181,8 +435,22 @@ func (m *modbusClient) WriteMultipleCoils(address, offset uint16, values []bool)
 }
 
 func (m *modbusClient) WriteMultipleRegisters(address, offset uint16, values []uint16) error {
+	return m.WriteMultipleRegistersContext(context.Background(), address, offset, values)
+}
+
+func (m *modbusClient) WriteMultipleRegistersContext(ctx context.Context, address, offset uint16, values []uint16) error {
+	blockSize := limit(m.requestLimits(address).WriteRegisters, MaxWriteRegisters)
+	return writeInBlocks(ctx, offset, values, blockSize, func(ctx context.Context, offset uint16, values []uint16) error {
+		return m.writeMultipleRegisters(ctx, address, offset, values)
+	})
+}
+
+func (m *modbusClient) writeMultipleRegisters(ctx context.Context, address, offset uint16, values []uint16) error {
 	req := data.NewWriteMultipleRegistersRequest(offset, values)
-	adu, err := m.sendRequestAndReadResponse(address, transport.NewProtocolDataUnit(req))
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
 	if err != nil {
 		return err
 	}
@@ -199,3 +467,278 @@ func (m *modbusClient) WriteMultipleRegisters(address, offset uint16, values []u
 		return nil
 	}
 }
+
+func (m *modbusClient) MaskWriteRegister(address, offset, andMask, orMask uint16) error {
+	return m.MaskWriteRegisterContext(context.Background(), address, offset, andMask, orMask)
+}
+
+func (m *modbusClient) MaskWriteRegisterContext(ctx context.Context, address, offset, andMask, orMask uint16) error {
+	req := data.NewMaskWriteRegisterRequest(offset, andMask, orMask)
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.MaskWriteRegisterResponse); !success {
+		return common.ErrInvalidPacket
+	} else {
+		if resp.Offset() != offset {
+			return common.ErrResponseOffsetMismatch
+		}
+		if resp.AndMask() != andMask || resp.OrMask() != orMask {
+			return common.ErrResponseValueMismatch
+		}
+		return nil
+	}
+}
+
+func (m *modbusClient) ReadWriteMultipleRegisters(address, readOffset, readQuantity, writeOffset uint16, values []uint16) ([]uint16, error) {
+	return m.ReadWriteMultipleRegistersContext(context.Background(), address, readOffset, readQuantity, writeOffset, values)
+}
+
+func (m *modbusClient) ReadWriteMultipleRegistersContext(ctx context.Context, address, readOffset, readQuantity, writeOffset uint16, values []uint16) ([]uint16, error) {
+	req := data.NewReadWriteMultipleRegistersRequest(readOffset, readQuantity, writeOffset, values)
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.ReadWriteMultipleRegistersResponse); !success {
+		return nil, common.ErrInvalidPacket
+	} else {
+		return resp.Values(), nil
+	}
+}
+
+func (m *modbusClient) ReadDeviceIdentification(address uint16, code data.DeviceIDCode, objectID data.DeviceObjectID) (map[data.DeviceObjectID]string, error) {
+	return m.ReadDeviceIdentificationContext(context.Background(), address, code, objectID)
+}
+
+func (m *modbusClient) ReadDeviceIdentificationContext(ctx context.Context, address uint16, code data.DeviceIDCode, objectID data.DeviceObjectID) (map[data.DeviceObjectID]string, error) {
+	objects := make(map[data.DeviceObjectID]string)
+	for {
+		req := data.NewReadDeviceIdentificationRequest(code, objectID)
+		adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+		if err != nil {
+			return nil, err
+		}
+		m.logger.Debug("Received modbus response", zap.Object("response", adu))
+		resp, success := adu.PDU().Operation().(*data.ReadDeviceIdentificationResponse)
+		if !success {
+			return nil, common.ErrInvalidPacket
+		}
+		for _, o := range resp.Objects() {
+			objects[o.ID] = string(o.Value)
+		}
+		if !resp.MoreFollows() || code == data.SpecificDeviceIdentification {
+			return objects, nil
+		}
+		// Guard against a server that keeps pointing us at objects we already have
+		if _, seen := objects[resp.NextObjectID()]; seen || len(resp.Objects()) == 0 {
+			return nil, common.ErrInvalidPacket
+		}
+		objectID = resp.NextObjectID()
+	}
+}
+
+func (m *modbusClient) Diagnostics(address uint16, subFunction data.DiagnosticSubFunction, value uint16) (uint16, error) {
+	return m.DiagnosticsContext(context.Background(), address, subFunction, value)
+}
+
+func (m *modbusClient) DiagnosticsContext(ctx context.Context, address uint16, subFunction data.DiagnosticSubFunction, value uint16) (uint16, error) {
+	req := data.NewDiagnosticsRequest(subFunction, []byte{byte(value >> 8), byte(value)})
+	if subFunction == data.ForceListenOnlyMode && m.isBroadcast(address) {
+		return 0, m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	if subFunction == data.ForceListenOnlyMode {
+		return 0, m.sendRequest(ctx, address, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return 0, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.DiagnosticsResponse); !success {
+		return 0, common.ErrInvalidPacket
+	} else {
+		if resp.SubFunction() != subFunction || len(resp.Data()) != 2 {
+			return 0, common.ErrInvalidPacket
+		}
+		result := uint16(resp.Data()[0])<<8 | uint16(resp.Data()[1])
+		if subFunction == data.ReturnQueryData && result != value {
+			return 0, common.ErrResponseValueMismatch
+		}
+		return result, nil
+	}
+}
+
+func (m *modbusClient) ReadExceptionStatus(address uint16) (byte, error) {
+	return m.ReadExceptionStatusContext(context.Background(), address)
+}
+
+func (m *modbusClient) ReadExceptionStatusContext(ctx context.Context, address uint16) (byte, error) {
+	req := data.NewReadExceptionStatusRequest()
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return 0, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.ReadExceptionStatusResponse); !success {
+		return 0, common.ErrInvalidPacket
+	} else {
+		return resp.ExceptionStatus(), nil
+	}
+}
+
+func (m *modbusClient) GetCommEventCounter(address uint16) (uint16, uint16, error) {
+	return m.GetCommEventCounterContext(context.Background(), address)
+}
+
+func (m *modbusClient) GetCommEventCounterContext(ctx context.Context, address uint16) (uint16, uint16, error) {
+	req := data.NewGetCommEventCounterRequest()
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return 0, 0, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.GetCommEventCounterResponse); !success {
+		return 0, 0, common.ErrInvalidPacket
+	} else {
+		return resp.Status(), resp.EventCount(), nil
+	}
+}
+
+func (m *modbusClient) GetCommEventLog(address uint16) (data.ModbusGetCommEventLogResponse, error) {
+	return m.GetCommEventLogContext(context.Background(), address)
+}
+
+func (m *modbusClient) GetCommEventLogContext(ctx context.Context, address uint16) (data.ModbusGetCommEventLogResponse, error) {
+	req := data.NewGetCommEventLogRequest()
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.GetCommEventLogResponse); !success {
+		return nil, common.ErrInvalidPacket
+	} else {
+		return resp, nil
+	}
+}
+
+func (m *modbusClient) ReportServerID(address uint16) ([]byte, bool, error) {
+	return m.ReportServerIDContext(context.Background(), address)
+}
+
+func (m *modbusClient) ReportServerIDContext(ctx context.Context, address uint16) ([]byte, bool, error) {
+	req := data.NewReportServerIDRequest()
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return nil, false, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.ReportServerIDResponse); !success {
+		return nil, false, common.ErrInvalidPacket
+	} else {
+		return resp.ServerID(), resp.RunIndicator(), nil
+	}
+}
+
+func (m *modbusClient) ReadFIFOQueue(address, pointer uint16) ([]uint16, error) {
+	return m.ReadFIFOQueueContext(context.Background(), address, pointer)
+}
+
+func (m *modbusClient) ReadFIFOQueueContext(ctx context.Context, address, pointer uint16) ([]uint16, error) {
+	req := data.NewReadFIFOQueueRequest(pointer)
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.ReadFIFOQueueResponse); !success {
+		return nil, common.ErrInvalidPacket
+	} else {
+		return resp.Queue(), nil
+	}
+}
+
+func (m *modbusClient) ReadFileRecord(address uint16, subRequests []data.FileSubRequest) ([][]uint16, error) {
+	return m.ReadFileRecordContext(context.Background(), address, subRequests)
+}
+
+func (m *modbusClient) ReadFileRecordContext(ctx context.Context, address uint16, subRequests []data.FileSubRequest) ([][]uint16, error) {
+	req := data.NewReadFileRecordRequest(subRequests)
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.ReadFileRecordResponse); !success {
+		return nil, common.ErrInvalidPacket
+	} else {
+		if len(resp.Records()) != len(subRequests) {
+			return nil, common.ErrInvalidPacket
+		}
+		for i, r := range resp.Records() {
+			if len(r) != int(subRequests[i].RecordLength) {
+				return nil, common.ErrInvalidPacket
+			}
+		}
+		return resp.Records(), nil
+	}
+}
+
+func (m *modbusClient) WriteFileRecord(address uint16, records []data.FileRecord) error {
+	return m.WriteFileRecordContext(context.Background(), address, records)
+}
+
+func (m *modbusClient) WriteFileRecordContext(ctx context.Context, address uint16, records []data.FileRecord) error {
+	req := data.NewWriteFileRecordRequest(records)
+	if m.isBroadcast(address) {
+		return m.broadcast(ctx, transport.NewProtocolDataUnit(req))
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(req))
+	if err != nil {
+		return err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(*data.WriteFileRecordResponse); !success {
+		return common.ErrInvalidPacket
+	} else {
+		if len(resp.FileRecords()) != len(records) {
+			return common.ErrInvalidPacket
+		}
+		for i, r := range resp.FileRecords() {
+			if r.FileNumber != records[i].FileNumber || r.RecordNumber != records[i].RecordNumber {
+				return common.ErrResponseOffsetMismatch
+			}
+			if !slices.Equal(r.Values, records[i].Values) {
+				return common.ErrResponseValueMismatch
+			}
+		}
+		return nil
+	}
+}
+
+func (m *modbusClient) CustomFunction(address uint16, request data.ModbusCustomOperation) (data.ModbusCustomOperation, error) {
+	return m.CustomFunctionContext(context.Background(), address, request)
+}
+
+func (m *modbusClient) CustomFunctionContext(ctx context.Context, address uint16, request data.ModbusCustomOperation) (data.ModbusCustomOperation, error) {
+	if _, ok := data.LookupCustomFunction(request.FunctionCode()); !ok {
+		return nil, common.ErrUnsupportedFunctionCode
+	}
+	adu, err := m.sendRequestAndReadResponse(ctx, address, transport.NewProtocolDataUnit(request))
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Debug("Received modbus response", zap.Object("response", adu))
+	if resp, success := adu.PDU().Operation().(data.ModbusCustomOperation); !success || resp.FunctionCode() != request.FunctionCode() {
+		return nil, common.ErrInvalidPacket
+	} else {
+		return resp, nil
+	}
+}
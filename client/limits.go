@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+)
+
+// The most items the spec allows a single request to read or write.
+const (
+	MaxReadBits       = 2000
+	MaxReadRegisters  = 125
+	MaxWriteBits      = 1968
+	MaxWriteRegisters = 123
+)
+
+// RequestLimits is the most items a single request to a device reads or writes, calls for more are split into several
+// requests and the results joined back together. Fields that are 0, or above what the spec allows, use the spec's limit.
+type RequestLimits struct {
+	// ReadBits limits Read Coils and Read Discrete Inputs
+	ReadBits int
+	// ReadRegisters limits Read Holding Registers and Read Input Registers
+	ReadRegisters int
+	// WriteBits limits Write Multiple Coils
+	WriteBits int
+	// WriteRegisters limits Write Multiple Registers
+	WriteRegisters int
+}
+
+func limit(value, max int) uint16 {
+	if value <= 0 || value > max {
+		return uint16(max)
+	}
+	return uint16(value)
+}
+
+func (m *modbusClient) SetRequestLimits(address uint16, limits RequestLimits) {
+	m.limitsMu.Lock()
+	defer m.limitsMu.Unlock()
+	m.limits[address] = limits
+}
+
+func (m *modbusClient) requestLimits(address uint16) RequestLimits {
+	m.limitsMu.RLock()
+	defer m.limitsMu.RUnlock()
+	return m.limits[address]
+}
+
+// readInBlocks reads quantity items starting at offset with as many requests of at most blockSize items as it takes
+func readInBlocks[T any](ctx context.Context, offset, quantity, blockSize uint16, read func(ctx context.Context, offset, quantity uint16) ([]T, error)) ([]T, error) {
+	if quantity <= blockSize {
+		return read(ctx, offset, quantity)
+	}
+	// A single request would be rejected by the device, but the blocks would wrap around to the start of the table
+	if int(offset)+int(quantity) > 0x10000 {
+		return nil, common.ErrInvalidCount
+	}
+	values := make([]T, 0, quantity)
+	for done := uint16(0); done < quantity; {
+		count := min(blockSize, quantity-done)
+		block, err := read(ctx, offset+done, count)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, block...)
+		done += count
+	}
+	return values, nil
+}
+
+// writeInBlocks writes values starting at offset with as many requests of at most blockSize items as it takes. The
+// blocks are written in order and it stops at the first one that fails, the blocks before it stay written.
+func writeInBlocks[T any](ctx context.Context, offset uint16, values []T, blockSize uint16, write func(ctx context.Context, offset uint16, values []T) error) error {
+	if len(values) <= int(blockSize) {
+		return write(ctx, offset, values)
+	}
+	if int(offset)+len(values) > 0x10000 {
+		return common.ErrInvalidCount
+	}
+	for done := 0; done < len(values); {
+		count := min(int(blockSize), len(values)-done)
+		if err := write(ctx, offset+uint16(done), values[done:done+count]); err != nil {
+			return err
+		}
+		done += count
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/codec"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// fieldMapping is where a struct field lives in a device, parsed from a tag like `modbus:"hr,100,float32,cdab"`
+type fieldMapping struct {
+	name   string
+	index  int
+	table  data.Table
+	offset uint16
+	// count is the number of registers the value takes, or 1 for bits
+	count     uint16
+	valueType reflect.Type
+	order     codec.ByteOrder
+}
+
+var (
+	tables = map[string]data.Table{
+		"coil": data.Coils,
+		"di":   data.DiscreteInputs,
+		"hr":   data.HoldingRegisters,
+		"ir":   data.InputRegisters,
+	}
+	valueTypes = map[string]reflect.Type{
+		"bool":    reflect.TypeFor[bool](),
+		"uint16":  reflect.TypeFor[uint16](),
+		"int16":   reflect.TypeFor[int16](),
+		"uint32":  reflect.TypeFor[uint32](),
+		"int32":   reflect.TypeFor[int32](),
+		"float32": reflect.TypeFor[float32](),
+		"uint64":  reflect.TypeFor[uint64](),
+		"int64":   reflect.TypeFor[int64](),
+		"float64": reflect.TypeFor[float64](),
+		"string":  reflect.TypeFor[string](),
+	}
+	mappingCache sync.Map
+)
+
+// structMappings parses the modbus tags of a struct type
+func structMappings(t reflect.Type) ([]fieldMapping, error) {
+	if cached, ok := mappingCache.Load(t); ok {
+		return cached.([]fieldMapping), nil
+	}
+	var mappings []fieldMapping
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("modbus")
+		if !ok || tag == "-" {
+			continue
+		}
+		mapping, err := parseTag(field, tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		mapping.index = i
+		mappings = append(mappings, mapping)
+	}
+	mappingCache.Store(t, mappings)
+	return mappings, nil
+}
+
+func parseTag(field reflect.StructField, tag string) (fieldMapping, error) {
+	parts := strings.Split(tag, ",")
+	if len(parts) < 2 || !field.IsExported() {
+		return fieldMapping{}, common.ErrInvalidTag
+	}
+	table, ok := tables[parts[0]]
+	if !ok {
+		return fieldMapping{}, common.ErrInvalidTag
+	}
+	offset, err := strconv.ParseUint(parts[1], 0, 16)
+	if err != nil {
+		return fieldMapping{}, errors.Join(common.ErrInvalidTag, err)
+	}
+	mapping := fieldMapping{name: field.Name, table: table, offset: uint16(offset), valueType: field.Type}
+	// The rest are the type, the byte order and the number of registers of a string, in any order and at most once each
+	length := 0
+	hasType, hasOrder := false, false
+	for _, part := range parts[2:] {
+		if valueType, ok := valueTypes[part]; ok && !hasType {
+			mapping.valueType = valueType
+			hasType = true
+		} else if order, err := codec.ParseByteOrder(part); err == nil && !hasOrder {
+			mapping.order = order
+			hasOrder = true
+		} else if n, err := strconv.Atoi(part); err == nil && n > 0 && length == 0 {
+			length = n
+		} else {
+			return fieldMapping{}, common.ErrInvalidTag
+		}
+	}
+	// Only strings have a length, every other type takes a fixed number of registers
+	if length != 0 && mapping.valueType.Kind() != reflect.String {
+		return fieldMapping{}, common.ErrInvalidTag
+	}
+
+	switch mapping.valueType.Kind() {
+	case reflect.Bool:
+		if !table.IsBits() || field.Type.Kind() != reflect.Bool {
+			return fieldMapping{}, common.ErrInvalidTag
+		}
+		mapping.count = 1
+		return mapping, nil
+	case reflect.String:
+		if table.IsBits() || field.Type.Kind() != reflect.String || length == 0 {
+			return fieldMapping{}, common.ErrInvalidTag
+		}
+		mapping.count = uint16(length)
+		return mapping, nil
+	case reflect.Uint16, reflect.Int16:
+		mapping.count = 1
+	case reflect.Uint32, reflect.Int32, reflect.Float32:
+		mapping.count = 2
+	case reflect.Uint64, reflect.Int64, reflect.Float64:
+		mapping.count = 4
+	default:
+		// Types like int need to be told how they are stored
+		return fieldMapping{}, common.ErrInvalidTag
+	}
+	if table.IsBits() || !isNumber(field.Type) {
+		return fieldMapping{}, common.ErrInvalidTag
+	}
+	mapping.valueType = valueTypes[mapping.valueType.Kind().String()]
+	return mapping, nil
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// decode sets a field from the bits or registers read for it
+func (m fieldMapping) decode(field reflect.Value, result ReadResult) error {
+	if m.table.IsBits() {
+		field.SetBool(result.Bits[0])
+		return nil
+	}
+	var value any
+	var err error
+	switch m.valueType.Kind() {
+	case reflect.String:
+		field.SetString(codec.DecodeString(result.Registers, m.order))
+		return nil
+	case reflect.Uint16:
+		value = result.Registers[0]
+	case reflect.Int16:
+		value = int16(result.Registers[0])
+	case reflect.Uint32:
+		value, err = decodeOne[uint32](result.Registers, m.order)
+	case reflect.Int32:
+		value, err = decodeOne[int32](result.Registers, m.order)
+	case reflect.Float32:
+		value, err = decodeOne[float32](result.Registers, m.order)
+	case reflect.Uint64:
+		value, err = decodeOne[uint64](result.Registers, m.order)
+	case reflect.Int64:
+		value, err = decodeOne[int64](result.Registers, m.order)
+	case reflect.Float64:
+		value, err = decodeOne[float64](result.Registers, m.order)
+	}
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(value).Convert(field.Type()))
+	return nil
+}
+
+func decodeOne[T codec.Value](registers []uint16, order codec.ByteOrder) (T, error) {
+	values, err := codec.Decode[T](registers, order)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return values[0], nil
+}
+
+// encodeRegisters converts a field to the registers it is stored in
+func (m fieldMapping) encodeRegisters(field reflect.Value) ([]uint16, error) {
+	if m.valueType.Kind() == reflect.String {
+		return codec.EncodeString(field.String(), int(m.count), m.order)
+	}
+	switch value := field.Convert(m.valueType).Interface().(type) {
+	case uint16:
+		return []uint16{value}, nil
+	case int16:
+		return []uint16{uint16(value)}, nil
+	case uint32:
+		return codec.Encode([]uint32{value}, m.order), nil
+	case int32:
+		return codec.Encode([]int32{value}, m.order), nil
+	case float32:
+		return codec.Encode([]float32{value}, m.order), nil
+	case uint64:
+		return codec.Encode([]uint64{value}, m.order), nil
+	case int64:
+		return codec.Encode([]int64{value}, m.order), nil
+	case float64:
+		return codec.Encode([]float64{value}, m.order), nil
+	}
+	return nil, common.ErrInvalidTag
+}
+
+func structValue(v any) (reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, common.ErrInvalidValue
+	}
+	return value, nil
+}
+
+// ReadStruct fills the fields of the struct v points to from a device, using the modbus tags of its fields to find them.
+// A tag names the table, one of coil, di, hr or ir, and the offset, followed by the type the value is stored as, its
+// codec.ByteOrder and, for strings, the number of registers, for example `modbus:"hr,100,float32,cdab"`,
+// `modbus:"coil,12"` or `modbus:"ir,0x20,string,8"`. The type can be left out when the field's own type is one of the
+// stored types. The fields are read with ReadBatch, so nearby fields share requests. When some of the reads fail the
+// other fields are still set.
+func ReadStruct(ctx context.Context, c ModbusClient, address uint16, v any, options BatchOptions) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return common.ErrInvalidValue
+	}
+	value = value.Elem()
+	mappings, err := structMappings(value.Type())
+	if err != nil {
+		return err
+	}
+	items := make([]ReadItem, len(mappings))
+	for i, m := range mappings {
+		items[i] = ReadItem{Address: address, Table: m.table, Offset: m.offset, Count: m.count}
+	}
+	results, err := c.ReadBatchContext(ctx, items, options)
+	if results == nil {
+		return err
+	}
+	errs := []error{err}
+	for i, m := range mappings {
+		if results[i].Err != nil {
+			continue
+		}
+		if decodeErr := m.decode(value.Field(m.index), results[i]); decodeErr != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", m.name, decodeErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fieldWrite is the new value of a run of coils or holding registers
+type fieldWrite struct {
+	table     data.Table
+	offset    uint16
+	bits      []bool
+	registers []uint16
+}
+
+// WriteStruct writes the coil and holding register fields of v to a device, see ReadStruct for how the fields are mapped.
+// When previous is not nil, only the fields whose value differs from previous, a struct of the same type, are written.
+// Fields that are next to each other are written with one request.
+func WriteStruct(ctx context.Context, c ModbusClient, address uint16, v any, previous any) error {
+	value, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	var old reflect.Value
+	if previous != nil {
+		if old, err = structValue(previous); err != nil {
+			return err
+		}
+		if old.Type() != value.Type() {
+			return common.ErrInvalidValue
+		}
+	}
+	mappings, err := structMappings(value.Type())
+	if err != nil {
+		return err
+	}
+
+	var writes []fieldWrite
+	for _, m := range mappings {
+		switch m.table {
+		case data.Coils:
+			bit := value.Field(m.index).Bool()
+			if old.IsValid() && old.Field(m.index).Bool() == bit {
+				continue
+			}
+			writes = append(writes, fieldWrite{table: m.table, offset: m.offset, bits: []bool{bit}})
+		case data.HoldingRegisters:
+			registers, err := m.encodeRegisters(value.Field(m.index))
+			if err != nil {
+				return fmt.Errorf("field %s: %w", m.name, err)
+			}
+			if old.IsValid() {
+				oldRegisters, err := m.encodeRegisters(old.Field(m.index))
+				if err == nil && slices.Equal(registers, oldRegisters) {
+					continue
+				}
+			}
+			writes = append(writes, fieldWrite{table: m.table, offset: m.offset, registers: registers})
+		}
+	}
+
+	slices.SortStableFunc(writes, func(a, b fieldWrite) int {
+		if a.table != b.table {
+			return int(a.table) - int(b.table)
+		}
+		return int(a.offset) - int(b.offset)
+	})
+	for i := 0; i < len(writes); {
+		run := writes[i]
+		// Join the writes that carry on where the previous one ended
+		for i++; i < len(writes) && writes[i].table == run.table && int(writes[i].offset) == int(run.offset)+len(run.bits)+len(run.registers); i++ {
+			run.bits = append(run.bits, writes[i].bits...)
+			run.registers = append(run.registers, writes[i].registers...)
+		}
+		if run.table == data.Coils {
+			err = c.WriteMultipleCoilsContext(ctx, address, run.offset, run.bits)
+		} else {
+			err = c.WriteMultipleRegistersContext(ctx, address, run.offset, run.registers)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
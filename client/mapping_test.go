@@ -0,0 +1,97 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/codec"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"github.com/stretchr/testify/assert"
+)
+
+type celsius float32
+
+func TestStructMappings(t *testing.T) {
+	type device struct {
+		Running     bool    `modbus:"coil,12"`
+		Alarm       bool    `modbus:"di,0x10"`
+		Setpoint    float32 `modbus:"hr,100,cdab"`
+		Temperature celsius `modbus:"ir,200,float32,dcba"`
+		Speed       int     `modbus:"hr,102,int16"`
+		Energy      uint64  `modbus:"ir,300"`
+		Serial      string  `modbus:"hr,400,string,8,badc"`
+		Ignored     int
+		Skipped     int `modbus:"-"`
+	}
+	mappings, err := structMappings(reflect.TypeFor[device]())
+	assert.NoError(t, err)
+	assert.Equal(t, []fieldMapping{
+		{name: "Running", index: 0, table: data.Coils, offset: 12, count: 1, valueType: reflect.TypeFor[bool]()},
+		{name: "Alarm", index: 1, table: data.DiscreteInputs, offset: 0x10, count: 1, valueType: reflect.TypeFor[bool]()},
+		{name: "Setpoint", index: 2, table: data.HoldingRegisters, offset: 100, count: 2, valueType: reflect.TypeFor[float32](), order: codec.CDAB},
+		{name: "Temperature", index: 3, table: data.InputRegisters, offset: 200, count: 2, valueType: reflect.TypeFor[float32](), order: codec.DCBA},
+		{name: "Speed", index: 4, table: data.HoldingRegisters, offset: 102, count: 1, valueType: reflect.TypeFor[int16]()},
+		{name: "Energy", index: 5, table: data.InputRegisters, offset: 300, count: 4, valueType: reflect.TypeFor[uint64]()},
+		{name: "Serial", index: 6, table: data.HoldingRegisters, offset: 400, count: 8, valueType: reflect.TypeFor[string](), order: codec.BADC},
+	}, mappings)
+}
+
+func TestInvalidTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "Unknown table", v: struct {
+			A uint16 `modbus:"xx,1"`
+		}{}},
+		{name: "Missing offset", v: struct {
+			A uint16 `modbus:"hr"`
+		}{}},
+		{name: "Invalid offset", v: struct {
+			A uint16 `modbus:"hr,70000"`
+		}{}},
+		{name: "Unknown option", v: struct {
+			A uint16 `modbus:"hr,1,uint128"`
+		}{}},
+		{name: "Register type without a size", v: struct {
+			A int `modbus:"hr,1"`
+		}{}},
+		{name: "Bool in a register", v: struct {
+			A bool `modbus:"hr,1"`
+		}{}},
+		{name: "Number in a coil", v: struct {
+			A uint16 `modbus:"coil,1"`
+		}{}},
+		{name: "String without a length", v: struct {
+			A string `modbus:"hr,1"`
+		}{}},
+		{name: "Number stored as a string", v: struct {
+			A int `modbus:"hr,1,string,4"`
+		}{}},
+		{name: "Length for a number", v: struct {
+			A float32 `modbus:"hr,1,float32,4"`
+		}{}},
+		{name: "Length for a bool", v: struct {
+			A bool `modbus:"coil,1,1"`
+		}{}},
+		{name: "Two types", v: struct {
+			A float32 `modbus:"hr,1,uint32,float32"`
+		}{}},
+		{name: "Two byte orders", v: struct {
+			A float32 `modbus:"hr,1,abcd,cdab"`
+		}{}},
+		{name: "Two lengths", v: struct {
+			A string `modbus:"hr,1,string,4,8"`
+		}{}},
+		{name: "Unexported", v: struct {
+			a uint16 `modbus:"hr,1"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := structMappings(reflect.TypeOf(tt.v))
+			assert.ErrorIs(t, err, common.ErrInvalidTag)
+		})
+	}
+}
@@ -7,7 +7,8 @@ import (
 
 	"github.com/rinzlerlabs/gomodbus/client"
 	settings "github.com/rinzlerlabs/gomodbus/settings/network"
-	transport "github.com/rinzlerlabs/gomodbus/transport/network"
+	"github.com/rinzlerlabs/gomodbus/transport"
+	networkTransport "github.com/rinzlerlabs/gomodbus/transport/network"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +29,26 @@ func NewModbusClientFromSettings(logger *zap.Logger, settings *settings.ClientSe
 }
 
 func NewModbusClientFromSettingsWithContext(ctx context.Context, logger *zap.Logger, settings *settings.ClientSettings) (client.ModbusClient, error) {
+	if settings.Reconnect {
+		return NewReconnectingModbusClient(ctx, logger, settings, nil), nil
+	}
+	conn, err := dial(ctx, logger, settings)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewModbusClientWithRetryPolicy(ctx, logger, newTransport(conn, logger, settings), retryPolicy(settings)), nil
+}
+
+func retryPolicy(settings *settings.ClientSettings) client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxAttempts: settings.RetryAttempts,
+		Backoff:     settings.RetryBackoff,
+		RetryWrites: settings.RetryWrites,
+		RetryOn:     settings.RetryOn,
+	}
+}
+
+func dial(ctx context.Context, logger *zap.Logger, settings *settings.ClientSettings) (net.Conn, error) {
 	dialer := net.Dialer{
 		Timeout:   settings.DialTimeout,
 		KeepAlive: settings.KeepAlive,
@@ -39,5 +60,12 @@ func NewModbusClientFromSettingsWithContext(ctx context.Context, logger *zap.Log
 		logger.Error("Failed to connect to endpoint", zap.String("endpoint", settings.Endpoint.String()), zap.Error(err))
 		return nil, err
 	}
-	return client.NewModbusClient(ctx, logger, transport.NewModbusClientTransport(conn, logger, settings.ResponseTimeout)), nil
+	return conn, nil
+}
+
+func newTransport(conn net.Conn, logger *zap.Logger, settings *settings.ClientSettings) transport.Transport {
+	if settings.MaxInFlight > 1 {
+		return networkTransport.NewModbusPipelinedClientTransport(conn, logger, settings.ResponseTimeout, settings.MaxInFlight)
+	}
+	return networkTransport.NewModbusClientTransport(conn, logger, settings.ResponseTimeout)
 }
@@ -2,10 +2,18 @@ package network
 
 import (
 	"context"
+	"io"
 	"net"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/codec"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
 	settings "github.com/rinzlerlabs/gomodbus/settings/network"
+	networkTransport "github.com/rinzlerlabs/gomodbus/transport/network"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -54,3 +62,297 @@ func TestNewModbusClientWithContext(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 }
+
+func TestPipelinedRequests(t *testing.T) {
+	const inFlight = 4
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, networkTransport.NewModbusPipelinedClientTransport(clientConn, logger, 5*time.Second, inFlight))
+	defer c.Close()
+
+	// The server doesn't answer until every request has arrived, which can only happen if they are sent concurrently
+	go func() {
+		requests := make([][]byte, inFlight)
+		for i := range requests {
+			requests[i] = make([]byte, 12)
+			if _, err := io.ReadFull(serverConn, requests[i]); err != nil {
+				return
+			}
+		}
+		for i := len(requests) - 1; i >= 0; i-- {
+			r := requests[i]
+			response := []byte{r[0], r[1], 0x00, 0x00, 0x00, 0x05, r[6], 0x03, 0x02, r[8], r[9]}
+			if _, err := serverConn.Write(response); err != nil {
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < inFlight; i++ {
+		wg.Add(1)
+		go func(offset uint16) {
+			defer wg.Done()
+			values, err := c.ReadHoldingRegisters(0x01, offset, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, []uint16{offset}, values)
+		}(uint16(i))
+	}
+	wg.Wait()
+}
+
+// fakeDevice answers register and coil requests from memory over a connection and records the quantity of each request,
+// requests that cover one of the holes get an Illegal Data Address exception
+type fakeDevice struct {
+	mu         sync.Mutex
+	registers  [0x10000]uint16
+	coils      [0x10000]bool
+	holes      map[int]bool
+	quantities []int
+}
+
+func (d *fakeDevice) serve(conn net.Conn) {
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, int(header[4])<<8|int(header[5])-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+		offset := int(pdu[1])<<8 | int(pdu[2])
+		quantity := int(pdu[3])<<8 | int(pdu[4])
+		d.mu.Lock()
+		d.quantities = append(d.quantities, quantity)
+		response := []byte{pdu[0]}
+		for i := offset; i < offset+quantity; i++ {
+			if d.holes[i] {
+				response = []byte{pdu[0] | 0x80, 0x02}
+			}
+		}
+		switch {
+		case len(response) > 1:
+		case pdu[0] == 0x01 || pdu[0] == 0x02:
+			bits := make([]byte, (quantity+7)/8)
+			for i := 0; i < quantity; i++ {
+				if d.coils[offset+i] {
+					bits[i/8] |= 1 << (i % 8)
+				}
+			}
+			response = append(append(response, byte(len(bits))), bits...)
+		case pdu[0] == 0x03 || pdu[0] == 0x04:
+			response = append(response, byte(quantity*2))
+			for i := 0; i < quantity; i++ {
+				response = append(response, byte(d.registers[offset+i]>>8), byte(d.registers[offset+i]))
+			}
+		case pdu[0] == 0x0F:
+			for i := 0; i < quantity; i++ {
+				d.coils[offset+i] = pdu[6+i/8]&(1<<(i%8)) != 0
+			}
+			response = pdu[:5]
+		case pdu[0] == 0x10:
+			for i := 0; i < quantity; i++ {
+				d.registers[offset+i] = uint16(pdu[6+2*i])<<8 | uint16(pdu[7+2*i])
+			}
+			response = pdu[:5]
+		}
+		d.mu.Unlock()
+		frame := append([]byte{header[0], header[1], 0x00, 0x00, byte((len(response) + 1) >> 8), byte(len(response) + 1), header[6]}, response...)
+		if _, err := conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func (d *fakeDevice) takeQuantities() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	quantities := d.quantities
+	d.quantities = nil
+	return quantities
+}
+
+func TestRequestsSplitIntoBlocks(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	device := &fakeDevice{}
+	go device.serve(serverConn)
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, networkTransport.NewModbusClientTransport(clientConn, logger, 5*time.Second))
+	defer c.Close()
+
+	registers := make([]uint16, 1000)
+	for i := range registers {
+		registers[i] = uint16(i * 3)
+	}
+	assert.NoError(t, c.WriteMultipleRegisters(0x01, 0x0010, registers))
+	assert.Equal(t, []int{123, 123, 123, 123, 123, 123, 123, 123, 16}, device.takeQuantities())
+	values, err := c.ReadHoldingRegisters(0x01, 0x0010, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, registers, values)
+	assert.Equal(t, []int{125, 125, 125, 125, 125, 125, 125, 125}, device.takeQuantities())
+
+	coils := make([]bool, 3000)
+	for i := range coils {
+		coils[i] = i%3 == 0
+	}
+	assert.NoError(t, c.WriteMultipleCoils(0x01, 0x0005, coils))
+	assert.Equal(t, []int{1968, 1032}, device.takeQuantities())
+	bits, err := c.ReadCoils(0x01, 0x0005, 3000)
+	assert.NoError(t, err)
+	assert.Equal(t, coils, bits)
+	assert.Equal(t, []int{2000, 1000}, device.takeQuantities())
+
+	// A device that takes smaller blocks
+	c.SetRequestLimits(0x01, client.RequestLimits{ReadRegisters: 40, WriteRegisters: 30})
+	values, err = c.ReadHoldingRegisters(0x01, 0x0010, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, registers[:100], values)
+	assert.Equal(t, []int{40, 40, 20}, device.takeQuantities())
+	assert.NoError(t, c.WriteMultipleRegisters(0x01, 0x0010, registers[:70]))
+	assert.Equal(t, []int{30, 30, 10}, device.takeQuantities())
+	// Other devices keep the spec's limits
+	_, err = c.ReadHoldingRegisters(0x02, 0x0010, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{125, 75}, device.takeQuantities())
+
+	_, err = c.ReadHoldingRegisters(0x01, 0xFFF0, 100)
+	assert.ErrorIs(t, err, common.ErrInvalidCount)
+	assert.Empty(t, device.takeQuantities())
+}
+
+func TestReadBatch(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	device := &fakeDevice{holes: map[int]bool{105: true, 300: true}}
+	for i := range device.registers {
+		device.registers[i] = uint16(i)
+	}
+	device.coils[3] = true
+	go device.serve(serverConn)
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, networkTransport.NewModbusClientTransport(clientConn, logger, 5*time.Second))
+	defer c.Close()
+
+	items := []client.ReadItem{
+		{Address: 1, Table: data.HoldingRegisters, Offset: 20, Count: 2},
+		{Address: 1, Table: data.HoldingRegisters, Offset: 10, Count: 1},
+		{Address: 1, Table: data.InputRegisters, Offset: 12, Count: 3},
+		{Address: 1, Table: data.Coils, Offset: 2, Count: 2},
+		{Address: 1, Table: data.HoldingRegisters, Offset: 100, Count: 1},
+		{Address: 1, Table: data.HoldingRegisters, Offset: 110, Count: 1},
+		{Address: 1, Table: data.HoldingRegisters, Offset: 300, Count: 1},
+	}
+	results, err := c.ReadBatch(items, client.BatchOptions{MaxGap: 16})
+	assert.ErrorIs(t, err, common.ErrIllegalDataAddress)
+	assert.Equal(t, client.ReadResult{Registers: []uint16{20, 21}}, results[0])
+	assert.Equal(t, client.ReadResult{Registers: []uint16{10}}, results[1])
+	assert.Equal(t, client.ReadResult{Registers: []uint16{12, 13, 14}}, results[2])
+	assert.Equal(t, client.ReadResult{Bits: []bool{false, true}}, results[3])
+	// The gap between these two covers a hole, so they were read again on their own
+	assert.Equal(t, client.ReadResult{Registers: []uint16{100}}, results[4])
+	assert.Equal(t, client.ReadResult{Registers: []uint16{110}}, results[5])
+	assert.ErrorIs(t, results[6].Err, common.ErrIllegalDataAddress)
+	assert.Equal(t, []int{2, 12, 11, 1, 1, 1, 3}, device.takeQuantities())
+}
+
+func TestTypedValues(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	device := &fakeDevice{}
+	go device.serve(serverConn)
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, networkTransport.NewModbusClientTransport(clientConn, logger, 5*time.Second))
+	defer c.Close()
+	ctx := context.Background()
+
+	assert.NoError(t, client.WriteFloat32s(ctx, c, 0x01, 0x0000, []float32{123.456, -1}, codec.CDAB))
+	assert.Equal(t, []uint16{0xE979, 0x42F6, 0x0000, 0xBF80}, device.registers[0:4])
+	floats, err := client.ReadFloat32s(ctx, c, 0x01, data.HoldingRegisters, 0x0000, 2, codec.CDAB)
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{123.456, -1}, floats)
+
+	assert.NoError(t, client.WriteInt64s(ctx, c, 0x01, 0x0010, []int64{-2}, codec.ABCD))
+	ints, err := client.ReadInt64s(ctx, c, 0x01, data.InputRegisters, 0x0010, 1, codec.ABCD)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{-2}, ints)
+
+	// 100 float64s take 400 registers, more than one request can read, and the blocks end on a whole value
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i) / 4
+	}
+	device.takeQuantities()
+	assert.NoError(t, client.WriteFloat64s(ctx, c, 0x01, 0x0100, values, codec.DCBA))
+	assert.Equal(t, []int{120, 120, 120, 40}, device.takeQuantities())
+	read, err := client.ReadFloat64s(ctx, c, 0x01, data.HoldingRegisters, 0x0100, 100, codec.DCBA)
+	assert.NoError(t, err)
+	assert.Equal(t, values, read)
+	assert.Equal(t, []int{124, 124, 124, 28}, device.takeQuantities())
+
+	// The limits set for a device are rounded down to whole values too
+	c.SetRequestLimits(0x01, client.RequestLimits{ReadRegisters: 11, WriteRegisters: 7})
+	assert.NoError(t, client.WriteFloat32s(ctx, c, 0x01, 0x0200, []float32{1, 2, 3, 4, 5}, codec.ABCD))
+	assert.Equal(t, []int{6, 4}, device.takeQuantities())
+	floats, err = client.ReadFloat32s(ctx, c, 0x01, data.HoldingRegisters, 0x0200, 5, codec.ABCD)
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, 2, 3, 4, 5}, floats)
+	assert.Equal(t, []int{10}, device.takeQuantities())
+	c.SetRequestLimits(0x01, client.RequestLimits{})
+
+	assert.NoError(t, client.WriteString(ctx, c, 0x01, 0x0020, 8, "SN-1234", codec.ABCD))
+	s, err := client.ReadString(ctx, c, 0x01, data.HoldingRegisters, 0x0020, 8, codec.ABCD)
+	assert.NoError(t, err)
+	assert.Equal(t, "SN-1234", s)
+	assert.ErrorIs(t, client.WriteString(ctx, c, 0x01, 0x0020, 2, "SN-1234", codec.ABCD), common.ErrInvalidLength)
+
+	_, err = client.ReadUint32s(ctx, c, 0x01, data.Coils, 0x0000, 1, codec.ABCD)
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+}
+
+type pump struct {
+	Running  bool    `modbus:"coil,0"`
+	Reverse  bool    `modbus:"coil,1"`
+	Setpoint float32 `modbus:"hr,10,cdab"`
+	Speed    int     `modbus:"hr,12,int16"`
+	Model    string  `modbus:"hr,20,string,4"`
+	Hours    uint32  `modbus:"ir,30"`
+}
+
+func TestStructMapping(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	device := &fakeDevice{}
+	go device.serve(serverConn)
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, networkTransport.NewModbusClientTransport(clientConn, logger, 5*time.Second))
+	defer c.Close()
+	ctx := context.Background()
+
+	written := pump{Running: true, Setpoint: 42.5, Speed: -300, Model: "P-100"}
+	assert.NoError(t, client.WriteStruct(ctx, c, 0x01, &written, nil))
+	// The coils are written together, then Setpoint and Speed together, then Model
+	assert.Equal(t, []int{2, 3, 4}, device.takeQuantities())
+
+	var read pump
+	assert.NoError(t, client.ReadStruct(ctx, c, 0x01, &read, client.BatchOptions{MaxGap: 8}))
+	assert.Equal(t, written, read)
+	// The coils, the holding registers 10 to 23 and the input registers
+	assert.Equal(t, []int{2, 14, 2}, device.takeQuantities())
+
+	changed := read
+	changed.Reverse = true
+	changed.Speed = 250
+	assert.NoError(t, client.WriteStruct(ctx, c, 0x01, changed, read))
+	assert.Equal(t, []int{1, 1}, device.takeQuantities())
+	assert.True(t, device.coils[1])
+	assert.Equal(t, uint16(250), device.registers[12])
+
+	assert.NoError(t, client.WriteStruct(ctx, c, 0x01, changed, changed))
+	assert.Empty(t, device.takeQuantities())
+
+	assert.ErrorIs(t, client.ReadStruct(ctx, c, 0x01, read, client.BatchOptions{}), common.ErrInvalidValue)
+	assert.ErrorIs(t, client.WriteStruct(ctx, c, 0x01, changed, &struct{}{}), common.ErrInvalidValue)
+}
@@ -20,12 +20,12 @@ type networkRequestCreator struct {
 	newRequest    func(header transport.Header, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error)
 }
 
-func (s *networkRequestCreator) NewHeader(uint16) transport.Header {
+func (s *networkRequestCreator) NewHeader(address uint16) transport.Header {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.transactionId++
 	txnId := []byte{byte(s.transactionId >> 8), byte(s.transactionId & 0xff)}
-	return network.NewHeader(txnId, []byte{0x00, 0x00}, byte(0x01))
+	return network.NewHeader(txnId, []byte{0x00, 0x00}, byte(address))
 }
 
 func (s *networkRequestCreator) NewRequest(header transport.Header, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
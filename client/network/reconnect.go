@@ -0,0 +1,248 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	settings "github.com/rinzlerlabs/gomodbus/settings/network"
+	"github.com/rinzlerlabs/gomodbus/transport"
+	"go.uber.org/zap"
+)
+
+type ConnectionState int
+
+const (
+	Disconnected ConnectionState = iota
+	Reconnecting
+	Connected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Disconnected:
+		return "Disconnected"
+	case Reconnecting:
+		return "Reconnecting"
+	case Connected:
+		return "Connected"
+	default:
+		return "Unknown"
+	}
+}
+
+// ConnectionStateHandler is called every time the connection of a reconnecting client changes state, err is the reason
+// the connection was lost and is nil otherwise. Failed dials while reconnecting are logged rather than reported.
+type ConnectionStateHandler func(state ConnectionState, err error)
+
+// NewReconnectingModbusClient creates a client that redials the endpoint whenever the connection fails, with the backoff
+// and jitter from the settings. Calls made while it is reconnecting fail with ErrNotConnected. The first connection is
+// made in the background too, so the client can be created before the device is reachable. onStateChange may be nil.
+func NewReconnectingModbusClient(ctx context.Context, logger *zap.Logger, settings *settings.ClientSettings, onStateChange ConnectionStateHandler) client.ModbusClient {
+	return client.NewModbusClientWithRetryPolicy(ctx, logger, newReconnectingTransport(ctx, logger, settings, onStateChange, dial), retryPolicy(settings))
+}
+
+// The waits used when the settings were built by hand and leave them unset, the same as the URI defaults
+const (
+	defaultReconnectMinBackoff = 500 * time.Millisecond
+	defaultReconnectMaxBackoff = 30 * time.Second
+)
+
+type dialFunc func(ctx context.Context, logger *zap.Logger, settings *settings.ClientSettings) (net.Conn, error)
+
+// reconnectingTransport passes requests to the transport of the current connection and replaces it when it fails
+type reconnectingTransport struct {
+	logger        *zap.Logger
+	settings      *settings.ClientSettings
+	onStateChange ConnectionStateHandler
+	dial          dialFunc
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	jitter        float64
+	ctx           context.Context
+	cancel        context.CancelFunc
+	mu            sync.Mutex
+	current       transport.Transport
+	wg            sync.WaitGroup
+}
+
+func newReconnectingTransport(ctx context.Context, logger *zap.Logger, settings *settings.ClientSettings, onStateChange ConnectionStateHandler, dial dialFunc) *reconnectingTransport {
+	ctx, cancel := context.WithCancel(ctx)
+	minBackoff := settings.ReconnectMinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultReconnectMinBackoff
+	}
+	maxBackoff := settings.ReconnectMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultReconnectMaxBackoff
+	}
+	t := &reconnectingTransport{
+		logger:        logger,
+		settings:      settings,
+		onStateChange: onStateChange,
+		dial:          dial,
+		minBackoff:    minBackoff,
+		maxBackoff:    max(maxBackoff, minBackoff),
+		jitter:        min(max(settings.ReconnectJitter, 0), 1),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	t.wg.Add(1)
+	go t.reconnect()
+	return t
+}
+
+// requestADU remembers which connection a request was written to, so we read the response from the same one
+type requestADU struct {
+	transport.ApplicationDataUnit
+	via transport.Transport
+}
+
+func (t *reconnectingTransport) connection() (transport.Transport, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return nil, common.ErrNotConnected
+	}
+	return t.current, nil
+}
+
+func (t *reconnectingTransport) WriteRequestFrame(address uint16, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
+	conn, err := t.connection()
+	if err != nil {
+		return nil, err
+	}
+	adu, err := conn.WriteRequestFrame(address, pdu)
+	if err != nil {
+		t.checkConnection(conn, err)
+		return nil, err
+	}
+	return &requestADU{ApplicationDataUnit: adu, via: conn}, nil
+}
+
+func (t *reconnectingTransport) ReadResponse(ctx context.Context, request transport.ApplicationDataUnit) (transport.ApplicationDataUnit, error) {
+	req, ok := request.(*requestADU)
+	if !ok {
+		return nil, common.ErrInvalidPacket
+	}
+	adu, err := req.via.ReadResponse(ctx, req.ApplicationDataUnit)
+	if err != nil {
+		t.checkConnection(req.via, err)
+		return nil, err
+	}
+	return adu, nil
+}
+
+func (t *reconnectingTransport) ReadRequest(context.Context) (transport.ApplicationDataUnit, error) {
+	return nil, common.ErrNotImplemented
+}
+
+func (t *reconnectingTransport) WriteResponseFrame(transport.Header, *transport.ProtocolDataUnit) error {
+	return common.ErrNotImplemented
+}
+
+func (t *reconnectingTransport) Flush(ctx context.Context) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Flush(ctx)
+}
+
+func (t *reconnectingTransport) MaxInFlight() int {
+	return t.settings.MaxInFlight
+}
+
+func (t *reconnectingTransport) Close() error {
+	t.mu.Lock()
+	t.cancel()
+	t.mu.Unlock()
+	t.wg.Wait()
+	t.mu.Lock()
+	conn := t.current
+	t.current = nil
+	t.mu.Unlock()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// isConnectionError reports whether err means the connection is gone, as opposed to a device that is slow or answered
+// with something we didn't like
+func isConnectionError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, common.ErrInvalidHeader) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
+
+// checkConnection drops the connection and starts reconnecting if err means it has failed
+func (t *reconnectingTransport) checkConnection(conn transport.Transport, err error) {
+	if !isConnectionError(err) {
+		return
+	}
+	t.mu.Lock()
+	if t.current != conn || t.ctx.Err() != nil {
+		// Another call already noticed, or we are closing
+		t.mu.Unlock()
+		return
+	}
+	t.current = nil
+	// Close cancels under the lock, so it can't start waiting before this reconnect is counted
+	t.wg.Add(1)
+	t.mu.Unlock()
+	t.logger.Warn("Lost connection to endpoint", zap.String("endpoint", t.settings.Endpoint.String()), zap.Error(err))
+	conn.Close()
+	t.setState(Disconnected, err)
+	go t.reconnect()
+}
+
+func (t *reconnectingTransport) reconnect() {
+	defer t.wg.Done()
+	backoff := t.minBackoff
+	t.setState(Reconnecting, nil)
+	for {
+		conn, err := t.dial(t.ctx, t.logger, t.settings)
+		if err == nil {
+			t.mu.Lock()
+			if t.ctx.Err() != nil {
+				t.mu.Unlock()
+				conn.Close()
+				return
+			}
+			t.current = newTransport(conn, t.logger, t.settings)
+			t.mu.Unlock()
+			t.logger.Info("Connected to endpoint", zap.String("endpoint", t.settings.Endpoint.String()))
+			t.setState(Connected, nil)
+			return
+		}
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(withJitter(backoff, t.jitter)):
+		}
+		backoff = min(backoff*2, t.maxBackoff)
+	}
+}
+
+// withJitter moves d up or down by a random amount of up to jitter times d
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	return time.Duration(float64(d) * (1 + jitter*(2*rand.Float64()-1)))
+}
+
+func (t *reconnectingTransport) setState(state ConnectionState, err error) {
+	if t.onStateChange != nil {
+		t.onStateChange(state, err)
+	}
+}
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	settings "github.com/rinzlerlabs/gomodbus/settings/network"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// serveHoldingRegisters answers Read Holding Registers requests for a single register with the register's address
+func serveHoldingRegisters(conn net.Conn) {
+	for {
+		r := make([]byte, 12)
+		if _, err := io.ReadFull(conn, r); err != nil {
+			return
+		}
+		response := []byte{r[0], r[1], 0x00, 0x00, 0x00, 0x05, r[6], 0x03, 0x02, r[8], r[9]}
+		if _, err := conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+func TestReconnectingClient(t *testing.T) {
+	s, err := settings.NewClientSettingsFromURI("tcp://:502?reconnect=true&reconnectMinBackoff=10ms&reconnectMaxBackoff=20ms")
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	dials := 0
+	servers := make(chan net.Conn, 2)
+	dial := func(context.Context, *zap.Logger, *settings.ClientSettings) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dials++
+		if dials == 1 {
+			return nil, errors.New("connection refused")
+		}
+		clientConn, serverConn := net.Pipe()
+		go serveHoldingRegisters(serverConn)
+		servers <- serverConn
+		return clientConn, nil
+	}
+	states := make(chan ConnectionState, 16)
+	onStateChange := func(state ConnectionState, err error) {
+		states <- state
+	}
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, newReconnectingTransport(context.Background(), logger, s, onStateChange, dial))
+	defer c.Close()
+
+	nextState := func() ConnectionState {
+		t.Helper()
+		select {
+		case state := <-states:
+			return state
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a state change")
+			return Disconnected
+		}
+	}
+
+	// The first dial fails, the client keeps trying without reporting every attempt
+	assert.Equal(t, Reconnecting, nextState())
+	assert.Equal(t, Connected, nextState())
+	values, err := c.ReadHoldingRegisters(0x01, 0x0001, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{0x0001}, values)
+
+	// The server drops the connection, the call fails and the client redials
+	(<-servers).Close()
+	_, err = c.ReadHoldingRegisters(0x01, 0x0002, 1)
+	assert.Error(t, err)
+	assert.Equal(t, Disconnected, nextState())
+	assert.Equal(t, Reconnecting, nextState())
+	assert.Equal(t, Connected, nextState())
+	values, err = c.ReadHoldingRegisters(0x01, 0x0003, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{0x0003}, values)
+	(<-servers).Close()
+}
+
+func TestReconnectingClientUnsetBackoff(t *testing.T) {
+	u, err := url.Parse("tcp://:502")
+	assert.NoError(t, err)
+	s := &settings.ClientSettings{NetworkSettings: settings.NetworkSettings{Endpoint: u}, Reconnect: true}
+	var dials atomic.Int32
+	dial := func(context.Context, *zap.Logger, *settings.ClientSettings) (net.Conn, error) {
+		dials.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	transport := newReconnectingTransport(context.Background(), zap.NewNop(), s, nil, dial)
+	assert.Equal(t, defaultReconnectMinBackoff, transport.minBackoff)
+	assert.Equal(t, defaultReconnectMaxBackoff, transport.maxBackoff)
+	// The first dial is made straight away, the next one waits for the backoff
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), dials.Load())
+	assert.NoError(t, transport.Close())
+}
+
+func TestReconnectingClientNotConnected(t *testing.T) {
+	s, err := settings.NewClientSettingsFromURI("tcp://:502?reconnect=true&reconnectMinBackoff=1h&reconnectMaxBackoff=1h")
+	assert.NoError(t, err)
+	dialed := make(chan struct{}, 1)
+	dial := func(context.Context, *zap.Logger, *settings.ClientSettings) (net.Conn, error) {
+		dialed <- struct{}{}
+		return nil, errors.New("connection refused")
+	}
+	logger := zap.NewNop()
+	c := client.NewModbusClient(context.Background(), logger, newReconnectingTransport(context.Background(), logger, s, nil, dial))
+	<-dialed
+
+	_, err = c.ReadHoldingRegisters(0x01, 0x0001, 1)
+	assert.ErrorIs(t, err, common.ErrNotConnected)
+	// Close stops the backoff wait
+	assert.NoError(t, c.Close())
+}
+
+func TestWithJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := withJitter(time.Second, 0.2)
+		assert.GreaterOrEqual(t, d, 800*time.Millisecond)
+		assert.LessOrEqual(t, d, 1200*time.Millisecond)
+	}
+	assert.Equal(t, time.Second, withJitter(time.Second, 0))
+}
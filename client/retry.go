@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// DefaultRetryOn is the errors a RetryPolicy retries when its RetryOn is nil. They all mean the request either didn't
+// reach the device intact or the device couldn't deal with it yet, rather than that the request is wrong.
+var DefaultRetryOn = []error{
+	common.ErrTimeout,
+	common.ErrInvalidChecksum,
+	common.ErrServerDeviceBusy,
+	common.ErrAcknowledge,
+}
+
+// RetryPolicy decides whether a failed call is made again. The zero value never retries.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a call is made before its error is returned, 0 and 1 both turn retries off.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, it doubles for every retry after that.
+	Backoff time.Duration
+	// RetryWrites allows calls that change the device to be retried. A write that timed out may still have been carried
+	// out, so only turn it on if repeating a write is harmless.
+	RetryWrites bool
+	// RetryOn is the errors that are retried, matched with errors.Is, DefaultRetryOn is used when it is nil.
+	RetryOn []error
+}
+
+// shouldRetry reports whether a request that failed with err on the given attempt is made again
+func (p RetryPolicy) shouldRetry(functionCode data.FunctionCode, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if !p.RetryWrites && !readOnly(functionCode) {
+		return false
+	}
+	retryOn := p.RetryOn
+	if retryOn == nil {
+		retryOn = DefaultRetryOn
+	}
+	for _, target := range retryOn {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnly reports whether a function leaves the device as it was, so repeating it is always safe. Diagnostics and
+// custom functions may change the device, so they are treated as writes.
+func readOnly(functionCode data.FunctionCode) bool {
+	switch functionCode {
+	case data.ReadCoils, data.ReadDiscreteInputs, data.ReadHoldingRegisters, data.ReadInputRegisters,
+		data.ReadExceptionStatus, data.GetCommEventCounter, data.GetCommEventLog, data.ReportServerID,
+		data.ReadFileRecord, data.ReadFIFOQueue, data.EncapsulatedInterfaceTransport:
+		return true
+	default:
+		return false
+	}
+}
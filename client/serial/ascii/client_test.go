@@ -81,7 +81,7 @@ func TestReadCoils(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadCoils(0x04, 10, 13)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -129,7 +129,7 @@ func TestReadDiscreteInputs(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadDiscreteInputs(0x04, 10, 13)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -177,7 +177,7 @@ func TestReadHoldingRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadHoldingRegisters(0x04, 0, 2)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -225,7 +225,7 @@ func TestReadInputRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadInputRegisters(0x04, 0, 2)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -279,7 +279,7 @@ func TestWriteSingleCoil(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteSingleCoil(0x04, 10, true)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -332,7 +332,7 @@ func TestWriteSingleRegister(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteSingleRegister(0x04, 16, 3)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -379,7 +379,7 @@ func TestWriteMultipleCoils(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteMultipleCoils(0x04, 0, tt.coils)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -426,7 +426,7 @@ func TestWriteMultipleRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteMultipleRegisters(0x04, 0, tt.registers)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
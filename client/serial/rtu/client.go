@@ -32,6 +32,15 @@ func NewModbusClientFromSettingsWithContext(ctx context.Context, logger *zap.Log
 	if err != nil {
 		return nil, err
 	}
-	t := rtu.NewModbusClientTransport(port, logger, settings.ResponseTimeout)
-	return client.NewModbusClient(ctx, logger, t), nil
+	t := rtu.NewModbusClientTransportWithTurnaroundDelay(port, logger, settings.ResponseTimeout, settings.TurnaroundDelay)
+	return client.NewModbusClientWithRetryPolicy(ctx, logger, t, retryPolicy(settings)), nil
+}
+
+func retryPolicy(settings *settings.ClientSettings) client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxAttempts: settings.RetryAttempts,
+		Backoff:     settings.RetryBackoff,
+		RetryWrites: settings.RetryWrites,
+		RetryOn:     settings.RetryOn,
+	}
 }
@@ -3,11 +3,13 @@ package rtu
 import (
 	"context"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/rinzlerlabs/gomodbus/client"
 	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/transport/serial/rtu"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -81,7 +83,7 @@ func TestReadCoils(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadCoils(0x04, 10, 13)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -129,7 +131,7 @@ func TestReadDiscreteInputs(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadDiscreteInputs(0x04, 10, 13)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -177,7 +179,7 @@ func TestReadHoldingRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadHoldingRegisters(0x04, 0, 2)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -225,7 +227,7 @@ func TestReadInputRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			resp, err := client.ReadInputRegisters(0x04, 0, 2)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -279,7 +281,7 @@ func TestWriteSingleCoil(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteSingleCoil(0x04, 10, true)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -332,7 +334,7 @@ func TestWriteSingleRegister(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteSingleRegister(0x04, 16, 3)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -379,7 +381,7 @@ func TestWriteMultipleCoils(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteMultipleCoils(0x04, 0, tt.coils)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -426,7 +428,7 @@ func TestWriteMultipleRegisters(t *testing.T) {
 			client := newModbusClient(logger, port, 1*time.Minute)
 			err := client.WriteMultipleRegisters(0x04, 0, tt.registers)
 			if tt.fromServerError != nil {
-				assert.Equal(t, tt.fromServerError, err)
+				assert.ErrorIs(t, err, tt.fromServerError)
 				return
 			}
 			assert.NoError(t, err)
@@ -434,3 +436,810 @@ func TestWriteMultipleRegisters(t *testing.T) {
 		})
 	}
 }
+
+func TestReadWriteMultipleRegisters(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		registers       []uint16
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x17, 0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x55, 0xC0},
+			registers:  []uint16{0x00FE, 0x0ACD, 0x0001, 0x0003, 0x000D, 0x00FF},
+			fromServer: []byte{0x04, 0x17, 0x0C, 0x00, 0xFE, 0x0A, 0xCD, 0x00, 0x01, 0x00, 0x03, 0x00, 0x0D, 0x00, 0xFF, 0xD8, 0x7A},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x17, 0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x55, 0xC0},
+			fromServer:      []byte{0x04, 0x97, 0x02, 0xDF, 0xF0},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidRequest_InvalidChecksum",
+			toServer:        []byte{0x04, 0x17, 0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x55, 0xC0},
+			fromServerError: common.ErrInvalidChecksum,
+			fromServer:      []byte{0x04, 0x17, 0x0C, 0x00, 0xFE, 0x0A, 0xCD, 0x00, 0x01, 0x00, 0x03, 0x00, 0x0D, 0x00, 0xFF, 0xD8, 0x7B},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			resp, err := client.ReadWriteMultipleRegisters(0x04, 3, 6, 14, []uint16{0x00FF, 0x00FF, 0x00FF})
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.registers, resp)
+		})
+	}
+}
+
+func TestMaskWriteRegister(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD1},
+			fromServer: []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD1},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD1},
+			fromServer:      []byte{0x04, 0x96, 0x02, 0xDE, 0x60},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidRequest_InvalidChecksum",
+			toServer:        []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD1},
+			fromServerError: common.ErrInvalidChecksum,
+			fromServer:      []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD2},
+		},
+		{
+			name:            "InvalidRequest_ResponseValueMismatch",
+			toServer:        []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x25, 0xA7, 0xD1},
+			fromServerError: common.ErrResponseValueMismatch,
+			fromServer:      []byte{0x04, 0x16, 0x00, 0x04, 0x00, 0xF2, 0x00, 0x26, 0xE7, 0xD0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			err := client.MaskWriteRegister(0x04, 4, 0x00F2, 0x0025)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name            string
+		subFunction     data.DiagnosticSubFunction
+		value           uint16
+		expected        uint16
+		toServer        []byte
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:        "ReturnQueryData",
+			subFunction: data.ReturnQueryData,
+			value:       0xA537,
+			expected:    0xA537,
+			toServer:    []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+			fromServer:  []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+		},
+		{
+			name:        "ReturnBusMessageCount",
+			subFunction: data.ReturnBusMessageCount,
+			expected:    0x0005,
+			toServer:    []byte{0x04, 0x08, 0x00, 0x0B, 0x00, 0x00, 0x91, 0x9C},
+			fromServer:  []byte{0x04, 0x08, 0x00, 0x0B, 0x00, 0x05, 0x51, 0x9F},
+		},
+		{
+			name:        "ForceListenOnlyMode_NoResponse",
+			subFunction: data.ForceListenOnlyMode,
+			toServer:    []byte{0x04, 0x08, 0x00, 0x04, 0x00, 0x00, 0xA1, 0x9F},
+		},
+		{
+			name:            "ServerError_IllegalFunction",
+			subFunction:     data.ReturnBusMessageCount,
+			toServer:        []byte{0x04, 0x08, 0x00, 0x0B, 0x00, 0x00, 0x91, 0x9C},
+			fromServer:      []byte{0x04, 0x88, 0x01, 0x97, 0xC1},
+			fromServerError: common.ErrIllegalFunction,
+		},
+		{
+			name:            "InvalidResponse_ResponseValueMismatch",
+			subFunction:     data.ReturnQueryData,
+			value:           0xA537,
+			toServer:        []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+			fromServer:      []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x38, 0x9A, 0xDC},
+			fromServerError: common.ErrResponseValueMismatch,
+		},
+		{
+			name:            "InvalidResponse_SubFunctionMismatch",
+			subFunction:     data.ReturnBusMessageCount,
+			toServer:        []byte{0x04, 0x08, 0x00, 0x0B, 0x00, 0x00, 0x91, 0x9C},
+			fromServer:      []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+			fromServerError: common.ErrInvalidPacket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			result, err := client.Diagnostics(0x04, tt.subFunction, tt.value)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.toServer, port.writeData)
+		})
+	}
+}
+
+func TestReadExceptionStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		status          byte
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x07, 0x42, 0xB2},
+			status:     0x3A,
+			fromServer: []byte{0x04, 0x07, 0x3A, 0xB2, 0x22},
+		},
+		{
+			name:            "ServerError_IllegalFunction",
+			toServer:        []byte{0x04, 0x07, 0x42, 0xB2},
+			fromServer:      []byte{0x04, 0x87, 0x01, 0x92, 0x31},
+			fromServerError: common.ErrIllegalFunction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			status, err := client.ReadExceptionStatus(0x04)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
+
+func TestGetCommEventCounter(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{0x04, 0x0B, 0x00, 0x00, 0x00, 0x02, 0x25, 0x9F},
+	}
+	client := newModbusClient(logger, port, 1*time.Minute)
+	status, eventCount, err := client.GetCommEventCounter(0x04)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x04, 0x0B, 0x42, 0xB7}, port.writeData)
+	assert.Equal(t, uint16(0x0000), status)
+	assert.Equal(t, uint16(0x0002), eventCount)
+}
+
+func TestGetCommEventLog(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{0x04, 0x0C, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00, 0x03, 0xC0, 0x80, 0x6C, 0x4B},
+	}
+	client := newModbusClient(logger, port, 1*time.Minute)
+	log, err := client.GetCommEventLog(0x04)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x04, 0x0C, 0x03, 0x75}, port.writeData)
+	assert.Equal(t, uint16(0x0000), log.Status())
+	assert.Equal(t, uint16(0x0002), log.EventCount())
+	assert.Equal(t, uint16(0x0003), log.MessageCount())
+	assert.Equal(t, []byte{0xC0, 0x80}, log.Events())
+}
+
+func TestReportServerID(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		serverID        []byte
+		runIndicator    bool
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:         "Valid",
+			toServer:     []byte{0x04, 0x11, 0xC3, 0x7C},
+			serverID:     []byte("PUMP-01"),
+			runIndicator: true,
+			fromServer:   []byte{0x04, 0x11, 0x08, 0x50, 0x55, 0x4D, 0x50, 0x2D, 0x30, 0x31, 0xFF, 0xB7, 0x1F},
+		},
+		{
+			name:       "Valid_NoServerID",
+			toServer:   []byte{0x04, 0x11, 0xC3, 0x7C},
+			serverID:   []byte{},
+			fromServer: []byte{0x04, 0x11, 0x01, 0x00, 0x50, 0x81},
+		},
+		{
+			name:            "ServerError_IllegalFunction",
+			toServer:        []byte{0x04, 0x11, 0xC3, 0x7C},
+			fromServer:      []byte{0x04, 0x91, 0x01, 0x9C, 0x51},
+			fromServerError: common.ErrIllegalFunction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			serverID, runIndicator, err := client.ReportServerID(0x04)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.serverID, serverID)
+			assert.Equal(t, tt.runIndicator, runIndicator)
+		})
+	}
+}
+
+func TestReadFIFOQueue(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		queue           []uint16
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x18, 0x04, 0xDE, 0x03, 0x8B},
+			queue:      []uint16{0x01B8, 0x1284},
+			fromServer: []byte{0x04, 0x18, 0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84, 0x09, 0x08},
+		},
+		{
+			name:       "Valid_Empty",
+			toServer:   []byte{0x04, 0x18, 0x04, 0xDE, 0x03, 0x8B},
+			queue:      []uint16{},
+			fromServer: []byte{0x04, 0x18, 0x00, 0x02, 0x00, 0x00, 0x80, 0x5D},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x18, 0x04, 0xDE, 0x03, 0x8B},
+			fromServer:      []byte{0x04, 0x98, 0x02, 0xDA, 0x00},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidResponse_InvalidChecksum",
+			toServer:        []byte{0x04, 0x18, 0x04, 0xDE, 0x03, 0x8B},
+			fromServer:      []byte{0x04, 0x18, 0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84, 0x09, 0x09},
+			fromServerError: common.ErrInvalidChecksum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			queue, err := client.ReadFIFOQueue(0x04, 0x04DE)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.queue, queue)
+		})
+	}
+}
+
+func TestReadDeviceIdentification(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		objects         map[data.DeviceObjectID]string
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x2B, 0x0E, 0x01, 0x00, 0xBC, 0x77},
+			objects:    map[data.DeviceObjectID]string{data.VendorName: "ABC", data.ProductCode: "P1", data.MajorMinorRevision: "1.0"},
+			fromServer: []byte{0x04, 0x2B, 0x0E, 0x01, 0x81, 0x00, 0x00, 0x03, 0x00, 0x03, 0x41, 0x42, 0x43, 0x01, 0x02, 0x50, 0x31, 0x02, 0x03, 0x31, 0x2E, 0x30, 0x54, 0x28},
+		},
+		{
+			name:     "Valid_MoreFollows",
+			toServer: []byte{0x04, 0x2B, 0x0E, 0x01, 0x02, 0x3D, 0xB6},
+			objects:  map[data.DeviceObjectID]string{data.VendorName: "ABC", data.ProductCode: "P1", data.MajorMinorRevision: "1.0"},
+			fromServer: []byte{
+				0x04, 0x2B, 0x0E, 0x01, 0x81, 0xFF, 0x02, 0x02, 0x00, 0x03, 0x41, 0x42, 0x43, 0x01, 0x02, 0x50, 0x31, 0xE4, 0xA8,
+				0x04, 0x2B, 0x0E, 0x01, 0x81, 0x00, 0x00, 0x01, 0x02, 0x03, 0x31, 0x2E, 0x30, 0x54, 0x5E,
+			},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x2B, 0x0E, 0x01, 0x00, 0xBC, 0x77},
+			fromServer:      []byte{0x04, 0xAB, 0x02, 0xCE, 0xF0},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidRequest_InvalidChecksum",
+			toServer:        []byte{0x04, 0x2B, 0x0E, 0x01, 0x00, 0xBC, 0x77},
+			fromServerError: common.ErrInvalidChecksum,
+			fromServer:      []byte{0x04, 0x2B, 0x0E, 0x01, 0x81, 0x00, 0x00, 0x03, 0x00, 0x03, 0x41, 0x42, 0x43, 0x01, 0x02, 0x50, 0x31, 0x02, 0x03, 0x31, 0x2E, 0x30, 0x54, 0x29},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Minute)
+			resp, err := client.ReadDeviceIdentification(0x04, data.BasicDeviceIdentification, data.VendorName)
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.objects, resp)
+		})
+	}
+}
+
+func TestReadFileRecord(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		records         [][]uint16
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x14, 0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0xE7, 0xAC},
+			records:    [][]uint16{{0x0DFE, 0x0020}, {0x33CD, 0x0040}},
+			fromServer: []byte{0x04, 0x14, 0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40, 0xBC, 0xA2},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x14, 0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0xE7, 0xAC},
+			fromServer:      []byte{0x04, 0x94, 0x02, 0xDF, 0x00},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidResponse_MissingSubResponse",
+			toServer:        []byte{0x04, 0x14, 0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0xE7, 0xAC},
+			fromServer:      []byte{0x04, 0x14, 0x06, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0xB4, 0x1E},
+			fromServerError: common.ErrInvalidPacket,
+		},
+		{
+			name:            "InvalidResponse_InvalidChecksum",
+			toServer:        []byte{0x04, 0x14, 0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0xE7, 0xAC},
+			fromServer:      []byte{0x04, 0x14, 0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40, 0xBC, 0xA3},
+			fromServerError: common.ErrInvalidChecksum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Second)
+			records, err := client.ReadFileRecord(0x04, []data.FileSubRequest{
+				{FileNumber: 0x0004, RecordNumber: 0x0001, RecordLength: 2},
+				{FileNumber: 0x0003, RecordNumber: 0x0009, RecordLength: 2},
+			})
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.records, records)
+		})
+	}
+}
+
+func TestWriteFileRecord(t *testing.T) {
+	tests := []struct {
+		name            string
+		toServer        []byte
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58},
+			fromServer: []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58},
+			fromServer:      []byte{0x04, 0x95, 0x02, 0xDE, 0x90},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidResponse_ValueMismatch",
+			toServer:        []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58},
+			fromServer:      []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0E, 0x55, 0x59},
+			fromServerError: common.ErrResponseValueMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Second)
+			err := client.WriteFileRecord(0x04, []data.FileRecord{
+				{FileNumber: 0x0004, RecordNumber: 0x0007, Values: []uint16{0x06AF, 0x04BE, 0x100D}},
+			})
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+		})
+	}
+}
+
+func TestCustomFunction(t *testing.T) {
+	assert.NoError(t, data.RegisterCustomFunction(data.CustomFunction{
+		FunctionCode:   0x41,
+		RequestLength:  data.FixedFrameLength(2),
+		ResponseLength: data.ByteCountFrameLength,
+	}))
+	t.Cleanup(func() { data.UnregisterCustomFunction(0x41) })
+
+	tests := []struct {
+		name            string
+		toServer        []byte
+		response        []byte
+		fromServerError error
+		fromServer      []byte
+	}{
+		{
+			name:       "Valid",
+			toServer:   []byte{0x04, 0x41, 0x00, 0x01, 0x90, 0xC0},
+			response:   []byte{0x02, 0xAB, 0xCD},
+			fromServer: []byte{0x04, 0x41, 0x02, 0xAB, 0xCD, 0xDE, 0x99},
+		},
+		{
+			name:            "ServerError_IllegalDataAddress",
+			toServer:        []byte{0x04, 0x41, 0x00, 0x01, 0x90, 0xC0},
+			fromServer:      []byte{0x04, 0xC1, 0x02, 0xE0, 0x50},
+			fromServerError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:            "InvalidResponse_InvalidChecksum",
+			toServer:        []byte{0x04, 0x41, 0x00, 0x01, 0x90, 0xC0},
+			fromServer:      []byte{0x04, 0x41, 0x02, 0xAB, 0xCD, 0xDE, 0x98},
+			fromServerError: common.ErrInvalidChecksum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.fromServer),
+			}
+			client := newModbusClient(logger, port, 1*time.Second)
+			resp, err := client.CustomFunction(0x04, data.NewCustomOperation(0x41, []byte{0x00, 0x01}))
+			if tt.fromServerError != nil {
+				assert.ErrorIs(t, err, tt.fromServerError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.toServer, port.writeData)
+			assert.Equal(t, tt.response, resp.Bytes())
+		})
+	}
+}
+
+// silentSerialPort never answers, reads block until the port is closed
+type silentSerialPort struct {
+	closed chan struct{}
+}
+
+func (t *silentSerialPort) Read(b []byte) (n int, err error) {
+	<-t.closed
+	return 0, io.EOF
+}
+
+func (t *silentSerialPort) Write(b []byte) (n int, err error) {
+	return len(b), nil
+}
+
+func (t *silentSerialPort) Close() error {
+	close(t.closed)
+	return nil
+}
+
+func TestContextDeadline(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &silentSerialPort{closed: make(chan struct{})}
+	client := newModbusClient(logger, port, 1*time.Minute)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ReadHoldingRegistersContext(ctx, 0x04, 0x006B, 3)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestContextCancelledWhileWaiting(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &silentSerialPort{closed: make(chan struct{})}
+	client := newModbusClient(logger, port, 1*time.Minute)
+	defer client.Close()
+
+	// The first call holds the client until it is cancelled
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := client.ReadHoldingRegistersContext(firstCtx, 0x04, 0x006B, 3)
+		firstDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err := client.WriteSingleRegisterContext(ctx, 0x04, 0x0001, 0x0003)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	firstCancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+}
+
+func TestClientContextCancelsCalls(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &silentSerialPort{closed: make(chan struct{})}
+	clientCtx, clientCancel := context.WithCancel(context.Background())
+	client := client.NewModbusClient(clientCtx, logger, rtu.NewModbusClientTransport(port, logger, 1*time.Minute))
+	defer client.Close()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		clientCancel()
+	}()
+	_, err := client.ReadCoilsContext(context.Background(), 0x04, 0x000A, 13)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// scriptedSerialPort answers the nth request written to it with the nth response, anything left unread of the earlier
+// responses stays in front of it
+type scriptedSerialPort struct {
+	mu        sync.Mutex
+	responses [][]byte
+	readData  []byte
+	writes    int
+}
+
+func (t *scriptedSerialPort) Read(b []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.readData) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(b, t.readData)
+	t.readData = t.readData[n:]
+	return n, nil
+}
+
+func (t *scriptedSerialPort) Write(b []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.writes < len(t.responses) {
+		t.readData = append(t.readData, t.responses[t.writes]...)
+	}
+	t.writes++
+	return len(b), nil
+}
+
+func (t *scriptedSerialPort) Close() error {
+	return nil
+}
+
+func TestRetryPolicy(t *testing.T) {
+	valid := []byte{0x04, 0x03, 0x02, 0x12, 0x34, 0x79, 0x33}
+	badChecksum := []byte{0x04, 0x03, 0x02, 0x12, 0x34, 0x79, 0x34}
+	busy := []byte{0x04, 0x83, 0x06, 0xD1, 0x33}
+	acknowledge := []byte{0x04, 0x83, 0x05, 0x91, 0x32}
+	illegalAddress := []byte{0x04, 0x83, 0x02, 0xD0, 0xF0}
+	writeEcho := []byte{0x04, 0x06, 0x00, 0x0A, 0x12, 0x34, 0xA4, 0xEA}
+	writeBadChecksum := []byte{0x04, 0x06, 0x00, 0x0A, 0x12, 0x34, 0xA4, 0xEB}
+	tests := []struct {
+		name      string
+		policy    client.RetryPolicy
+		write     bool
+		responses [][]byte
+		err       error
+		writes    int
+	}{
+		{
+			name:      "No policy",
+			responses: [][]byte{badChecksum, valid},
+			err:       common.ErrInvalidChecksum,
+			writes:    1,
+		},
+		{
+			name:      "Checksum",
+			policy:    client.RetryPolicy{MaxAttempts: 3},
+			responses: [][]byte{badChecksum, valid},
+			writes:    2,
+		},
+		{
+			name:      "Checksum with trailing bytes",
+			policy:    client.RetryPolicy{MaxAttempts: 3},
+			responses: [][]byte{append(badChecksum, 0x12, 0x34), valid},
+			writes:    2,
+		},
+		{
+			name:      "Busy and acknowledge",
+			policy:    client.RetryPolicy{MaxAttempts: 3},
+			responses: [][]byte{busy, acknowledge, valid},
+			writes:    3,
+		},
+		{
+			name:      "Attempts exhausted",
+			policy:    client.RetryPolicy{MaxAttempts: 2},
+			responses: [][]byte{busy, busy, valid},
+			err:       common.ErrServerDeviceBusy,
+			writes:    2,
+		},
+		{
+			name:      "Illegal data address is not retried",
+			policy:    client.RetryPolicy{MaxAttempts: 3},
+			responses: [][]byte{illegalAddress, valid},
+			err:       common.ErrIllegalDataAddress,
+			writes:    1,
+		},
+		{
+			name:      "Custom RetryOn",
+			policy:    client.RetryPolicy{MaxAttempts: 3, RetryOn: []error{common.ErrIllegalDataAddress}},
+			responses: [][]byte{illegalAddress, busy, valid},
+			err:       common.ErrServerDeviceBusy,
+			writes:    2,
+		},
+		{
+			name:      "Writes are not retried",
+			policy:    client.RetryPolicy{MaxAttempts: 3},
+			write:     true,
+			responses: [][]byte{writeBadChecksum, writeEcho},
+			err:       common.ErrInvalidChecksum,
+			writes:    1,
+		},
+		{
+			name:      "RetryWrites",
+			policy:    client.RetryPolicy{MaxAttempts: 3, RetryWrites: true},
+			write:     true,
+			responses: [][]byte{writeBadChecksum, writeEcho},
+			writes:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &scriptedSerialPort{responses: tt.responses}
+			tt.policy.Backoff = time.Millisecond
+			client := client.NewModbusClientWithRetryPolicy(context.Background(), logger, rtu.NewModbusClientTransport(port, logger, 1*time.Second), tt.policy)
+			defer client.Close()
+
+			var err error
+			if tt.write {
+				err = client.WriteSingleRegister(0x04, 0x000A, 0x1234)
+			} else {
+				var values []uint16
+				values, err = client.ReadHoldingRegisters(0x04, 0x000A, 1)
+				if tt.err == nil {
+					assert.Equal(t, []uint16{0x1234}, values)
+				}
+			}
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.writes, port.writes)
+		})
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{}
+	c := client.NewModbusClient(context.Background(), logger, rtu.NewModbusClientTransportWithTurnaroundDelay(port, logger, time.Second, 50*time.Millisecond))
+	defer c.Close()
+
+	// Nothing answers, the call returns once the turnaround delay is over
+	start := time.Now()
+	err := c.WriteSingleCoil(0x00, 0x000A, true)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, []byte{0x00, 0x05, 0x00, 0x0A, 0xFF, 0x00, 0xAD, 0xE9}, port.writeData)
+
+	err = c.WriteSingleRegister(0x00, 0x000A, 0x1234)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x06, 0x00, 0x0A, 0x12, 0x34, 0xA5, 0x6E}, port.writeData)
+
+	// Reads can't be broadcast since nothing would answer them
+	port.writeData = nil
+	_, err = c.ReadHoldingRegisters(0x00, 0x0000, 1)
+	assert.ErrorIs(t, err, common.ErrBroadcastNotSupported)
+	assert.Nil(t, port.writeData)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = c.WriteMultipleRegistersContext(ctx, 0x00, 0x0000, []uint16{1, 2})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
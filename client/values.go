@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+
+	"github.com/rinzlerlabs/gomodbus/codec"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// readRegisters reads count registers from the holding or input registers
+func readRegisters(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset uint16, count int) ([]uint16, error) {
+	if count > 0xFFFF {
+		return nil, common.ErrInvalidCount
+	}
+	switch table {
+	case data.HoldingRegisters:
+		return c.ReadHoldingRegistersContext(ctx, address, offset, uint16(count))
+	case data.InputRegisters:
+		return c.ReadInputRegistersContext(ctx, address, offset, uint16(count))
+	default:
+		return nil, common.ErrInvalidValue
+	}
+}
+
+// limitsOf returns the request limits set for a device, clients that weren't made by this package use the spec's limits
+func limitsOf(c ModbusClient, address uint16) RequestLimits {
+	if l, ok := c.(interface{ requestLimits(uint16) RequestLimits }); ok {
+		return l.requestLimits(address)
+	}
+	return RequestLimits{}
+}
+
+// alignBlockSize rounds a block size down to a whole number of values that are width registers wide, so no value is
+// split across two requests. A block smaller than one value is left for the client to split.
+func alignBlockSize(blockSize uint16, width int) uint16 {
+	aligned := blockSize - blockSize%uint16(width)
+	if aligned == 0 {
+		return uint16(width)
+	}
+	return aligned
+}
+
+// ReadValues reads count values of type T from the holding or input registers of a device, starting at offset. Reads
+// that need more than one request are split between values, so every value is read in one piece.
+func ReadValues[T codec.Value](ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]T, error) {
+	width := codec.RegisterCount[T]()
+	quantity := int(count) * width
+	if quantity > 0xFFFF {
+		return nil, common.ErrInvalidCount
+	}
+	blockSize := alignBlockSize(limit(limitsOf(c, address).ReadRegisters, MaxReadRegisters), width)
+	registers, err := readInBlocks(ctx, offset, uint16(quantity), blockSize, func(ctx context.Context, offset, quantity uint16) ([]uint16, error) {
+		return readRegisters(ctx, c, address, table, offset, int(quantity))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode[T](registers, order)
+}
+
+// WriteValues writes values of type T to the holding registers of a device, starting at offset. Writes that need more
+// than one request are split between values, so every value is written in one piece.
+func WriteValues[T codec.Value](ctx context.Context, c ModbusClient, address, offset uint16, values []T, order codec.ByteOrder) error {
+	blockSize := alignBlockSize(limit(limitsOf(c, address).WriteRegisters, MaxWriteRegisters), codec.RegisterCount[T]())
+	return writeInBlocks(ctx, offset, codec.Encode(values, order), blockSize, func(ctx context.Context, offset uint16, values []uint16) error {
+		return c.WriteMultipleRegistersContext(ctx, address, offset, values)
+	})
+}
+
+// ReadInt32s reads count 32 bit signed integers from the holding or input registers of a device.
+func ReadInt32s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]int32, error) {
+	return ReadValues[int32](ctx, c, address, table, offset, count, order)
+}
+
+// ReadUint32s reads count 32 bit unsigned integers from the holding or input registers of a device.
+func ReadUint32s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]uint32, error) {
+	return ReadValues[uint32](ctx, c, address, table, offset, count, order)
+}
+
+// ReadFloat32s reads count 32 bit floats from the holding or input registers of a device.
+func ReadFloat32s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]float32, error) {
+	return ReadValues[float32](ctx, c, address, table, offset, count, order)
+}
+
+// ReadInt64s reads count 64 bit signed integers from the holding or input registers of a device.
+func ReadInt64s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]int64, error) {
+	return ReadValues[int64](ctx, c, address, table, offset, count, order)
+}
+
+// ReadUint64s reads count 64 bit unsigned integers from the holding or input registers of a device.
+func ReadUint64s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]uint64, error) {
+	return ReadValues[uint64](ctx, c, address, table, offset, count, order)
+}
+
+// ReadFloat64s reads count 64 bit floats from the holding or input registers of a device.
+func ReadFloat64s(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) ([]float64, error) {
+	return ReadValues[float64](ctx, c, address, table, offset, count, order)
+}
+
+// WriteInt32s writes 32 bit signed integers to the holding registers of a device.
+func WriteInt32s(ctx context.Context, c ModbusClient, address, offset uint16, values []int32, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// WriteUint32s writes 32 bit unsigned integers to the holding registers of a device.
+func WriteUint32s(ctx context.Context, c ModbusClient, address, offset uint16, values []uint32, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// WriteFloat32s writes 32 bit floats to the holding registers of a device.
+func WriteFloat32s(ctx context.Context, c ModbusClient, address, offset uint16, values []float32, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// WriteInt64s writes 64 bit signed integers to the holding registers of a device.
+func WriteInt64s(ctx context.Context, c ModbusClient, address, offset uint16, values []int64, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// WriteUint64s writes 64 bit unsigned integers to the holding registers of a device.
+func WriteUint64s(ctx context.Context, c ModbusClient, address, offset uint16, values []uint64, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// WriteFloat64s writes 64 bit floats to the holding registers of a device.
+func WriteFloat64s(ctx context.Context, c ModbusClient, address, offset uint16, values []float64, order codec.ByteOrder) error {
+	return WriteValues(ctx, c, address, offset, values, order)
+}
+
+// ReadString reads a string of two characters per register from count registers of a device, dropping the NUL padding.
+func ReadString(ctx context.Context, c ModbusClient, address uint16, table data.Table, offset, count uint16, order codec.ByteOrder) (string, error) {
+	registers, err := readRegisters(ctx, c, address, table, offset, int(count))
+	if err != nil {
+		return "", err
+	}
+	return codec.DecodeString(registers, order), nil
+}
+
+// WriteString writes a string of two characters per register to count holding registers of a device, padding it with
+// NULs. It fails with ErrInvalidLength if the string doesn't fit.
+func WriteString(ctx context.Context, c ModbusClient, address, offset, count uint16, value string, order codec.ByteOrder) error {
+	registers, err := codec.EncodeString(value, int(count), order)
+	if err != nil {
+		return err
+	}
+	return c.WriteMultipleRegistersContext(ctx, address, offset, registers)
+}
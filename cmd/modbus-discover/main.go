@@ -0,0 +1,111 @@
+// modbus-discover lists the devices that answer on a Modbus RTU or ASCII serial bus.
+//
+//	modbus-discover -uri "rtu:///dev/ttyUSB0?baud=9600&dataBits=8&parity=N&stopBits=1" -bauds 9600,19200 -parities N,E
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"github.com/rinzlerlabs/gomodbus/discovery"
+	settings "github.com/rinzlerlabs/gomodbus/settings/serial"
+	"go.uber.org/zap"
+)
+
+var tables = map[string]data.Table{
+	"coil": data.Coils,
+	"di":   data.DiscreteInputs,
+	"hr":   data.HoldingRegisters,
+	"ir":   data.InputRegisters,
+}
+
+func main() {
+	uri := flag.String("uri", "", "serial port to scan, e.g. rtu:///dev/ttyUSB0?baud=9600&dataBits=8&parity=N&stopBits=1")
+	first := flag.Uint("first", discovery.MinAddress, "first unit address to probe")
+	last := flag.Uint("last", discovery.MaxAddress, "last unit address to probe")
+	timeout := flag.Duration("timeout", discovery.DefaultTimeout, "how long to wait for each address to answer")
+	bauds := flag.String("bauds", "", "comma separated baud rates to sweep, defaults to the baud rate of the uri")
+	parities := flag.String("parities", "", "comma separated parities to sweep from N, E and O, defaults to the parity of the uri")
+	table := flag.String("table", "hr", "table the probe reads from, one of coil, di, hr or ir")
+	offset := flag.Uint("offset", 0, "offset the probe reads from")
+	serverID := flag.Bool("serverId", false, "ask every address for its server ID before reading")
+	verbose := flag.Bool("v", false, "log every request")
+	flag.Parse()
+
+	if err := run(*uri, *first, *last, *timeout, *bauds, *parities, *table, *offset, *serverID, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(uri string, first, last uint, timeout time.Duration, bauds, parities, table string, offset uint, serverID, verbose bool) error {
+	s, err := settings.NewClientSettingsFromURI(uri)
+	if err != nil {
+		return fmt.Errorf("invalid uri: %w", err)
+	}
+	probeTable, ok := tables[table]
+	if !ok {
+		return fmt.Errorf("invalid table %q", table)
+	}
+	options := discovery.Options{
+		FirstAddress:   uint16(first),
+		LastAddress:    uint16(last),
+		Timeout:        timeout,
+		Probe:          client.ReadItem{Table: probeTable, Offset: uint16(offset), Count: 1},
+		ReportServerID: serverID,
+		OnResult:       printResult,
+	}
+	if bauds != "" {
+		for _, baud := range strings.Split(bauds, ",") {
+			value, err := strconv.Atoi(strings.TrimSpace(baud))
+			if err != nil {
+				return fmt.Errorf("invalid baud rate %q", baud)
+			}
+			options.BaudRates = append(options.BaudRates, value)
+		}
+	}
+	if parities != "" {
+		for _, parity := range strings.Split(parities, ",") {
+			options.Parities = append(options.Parities, strings.ToUpper(strings.TrimSpace(parity)))
+		}
+	}
+
+	logger := zap.NewNop()
+	if verbose {
+		if logger, err = zap.NewDevelopment(); err != nil {
+			return err
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := discovery.Scan(ctx, logger, s.SerialSettings, options)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	fmt.Printf("%d devices found\n", len(results))
+	return nil
+}
+
+func printResult(r discovery.Result) {
+	line := fmt.Sprintf("address %d at %d baud, parity %s:", r.Address, r.Settings.Baud, r.Settings.Parity)
+	switch {
+	case r.ServerID != nil:
+		fmt.Printf("%s server ID %q, run indicator %t\n", line, r.ServerID, r.RunIndicator)
+	case r.Exception != nil:
+		fmt.Printf("%s exception %s\n", line, r.Exception.ExceptionCode)
+	case r.Err != nil:
+		fmt.Printf("%s unreadable response: %v\n", line, r.Err)
+	case r.Bits != nil:
+		fmt.Printf("%s %v\n", line, r.Bits)
+	default:
+		fmt.Printf("%s %#04x\n", line, r.Registers)
+	}
+}
@@ -0,0 +1,193 @@
+// Package codec converts between registers and the 32 and 64 bit integers, floats and strings devices store in them.
+// Modbus only defines 16 bit big endian registers, so how a wider value is laid out across registers varies by device,
+// every conversion takes the ByteOrder the device uses.
+package codec
+
+import (
+	"math"
+	"strings"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+)
+
+// ByteOrder is the order of the bytes of a value across registers, named after where the bytes of the 32 bit value
+// 0xAABBCCDD end up. 64 bit values follow the same pattern over four registers.
+type ByteOrder uint8
+
+const (
+	// ABCD is big endian, the most significant register first with its most significant byte first.
+	ABCD ByteOrder = iota
+	// CDAB is big endian registers with the least significant register first, often called word swapped.
+	CDAB
+	// BADC is the most significant register first with the bytes of each register swapped.
+	BADC
+	// DCBA is little endian.
+	DCBA
+)
+
+func (o ByteOrder) String() string {
+	switch o {
+	case ABCD:
+		return "ABCD"
+	case CDAB:
+		return "CDAB"
+	case BADC:
+		return "BADC"
+	case DCBA:
+		return "DCBA"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParseByteOrder parses the name of a ByteOrder, like "CDAB".
+func ParseByteOrder(s string) (ByteOrder, error) {
+	for _, o := range []ByteOrder{ABCD, CDAB, BADC, DCBA} {
+		if strings.EqualFold(s, o.String()) {
+			return o, nil
+		}
+	}
+	return 0, common.ErrInvalidValue
+}
+
+func (o ByteOrder) swapBytes() bool {
+	return o == BADC || o == DCBA
+}
+
+func (o ByteOrder) swapWords() bool {
+	return o == CDAB || o == DCBA
+}
+
+func swap(register uint16) uint16 {
+	return register<<8 | register>>8
+}
+
+// Value is the types that are stored across several registers.
+type Value interface {
+	int32 | uint32 | float32 | int64 | uint64 | float64
+}
+
+// RegisterCount returns the number of registers a value of type T takes.
+func RegisterCount[T Value]() int {
+	var v T
+	switch any(v).(type) {
+	case int32, uint32, float32:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// Decode converts registers to values, there must be a whole number of values in the registers.
+func Decode[T Value](registers []uint16, order ByteOrder) ([]T, error) {
+	width := RegisterCount[T]()
+	if len(registers)%width != 0 {
+		return nil, common.ErrInvalidLength
+	}
+	values := make([]T, len(registers)/width)
+	for i := range values {
+		var bits uint64
+		for j := 0; j < width; j++ {
+			register := registers[i*width+j]
+			if order.swapWords() {
+				register = registers[i*width+width-1-j]
+			}
+			if order.swapBytes() {
+				register = swap(register)
+			}
+			bits = bits<<16 | uint64(register)
+		}
+		values[i] = fromBits[T](bits)
+	}
+	return values, nil
+}
+
+// Encode converts values to registers.
+func Encode[T Value](values []T, order ByteOrder) []uint16 {
+	width := RegisterCount[T]()
+	registers := make([]uint16, len(values)*width)
+	for i, v := range values {
+		bits := toBits(v)
+		for j := width - 1; j >= 0; j-- {
+			register := uint16(bits)
+			bits >>= 16
+			if order.swapBytes() {
+				register = swap(register)
+			}
+			if order.swapWords() {
+				registers[i*width+width-1-j] = register
+			} else {
+				registers[i*width+j] = register
+			}
+		}
+	}
+	return registers
+}
+
+func fromBits[T Value](bits uint64) T {
+	var v T
+	switch p := any(&v).(type) {
+	case *int32:
+		*p = int32(uint32(bits))
+	case *uint32:
+		*p = uint32(bits)
+	case *float32:
+		*p = math.Float32frombits(uint32(bits))
+	case *int64:
+		*p = int64(bits)
+	case *uint64:
+		*p = bits
+	case *float64:
+		*p = math.Float64frombits(bits)
+	}
+	return v
+}
+
+func toBits[T Value](v T) uint64 {
+	switch v := any(v).(type) {
+	case int32:
+		return uint64(uint32(v))
+	case uint32:
+		return uint64(v)
+	case float32:
+		return uint64(math.Float32bits(v))
+	case int64:
+		return uint64(v)
+	case uint64:
+		return v
+	case float64:
+		return math.Float64bits(v)
+	}
+	return 0
+}
+
+// DecodeString converts registers holding two characters each to a string, dropping the NUL padding at the end. Only
+// the byte half of the order matters, CDAB reads the same as ABCD and DCBA the same as BADC.
+func DecodeString(registers []uint16, order ByteOrder) string {
+	b := make([]byte, 0, len(registers)*2)
+	for _, register := range registers {
+		if order.swapBytes() {
+			register = swap(register)
+		}
+		b = append(b, byte(register>>8), byte(register))
+	}
+	return strings.TrimRight(string(b), "\x00")
+}
+
+// EncodeString converts a string to count registers of two characters each, padding it with NULs. It fails with
+// ErrInvalidLength if the string doesn't fit.
+func EncodeString(s string, count int, order ByteOrder) ([]uint16, error) {
+	if len(s) > count*2 {
+		return nil, common.ErrInvalidLength
+	}
+	b := make([]byte, count*2)
+	copy(b, s)
+	registers := make([]uint16, count)
+	for i := range registers {
+		registers[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		if order.swapBytes() {
+			registers[i] = swap(registers[i])
+		}
+	}
+	return registers, nil
+}
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat32(t *testing.T) {
+	// 123.456 is 0x42F6E979
+	tests := []struct {
+		order     ByteOrder
+		registers []uint16
+	}{
+		{order: ABCD, registers: []uint16{0x42F6, 0xE979}},
+		{order: CDAB, registers: []uint16{0xE979, 0x42F6}},
+		{order: BADC, registers: []uint16{0xF642, 0x79E9}},
+		{order: DCBA, registers: []uint16{0x79E9, 0xF642}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			assert.Equal(t, tt.registers, Encode([]float32{123.456}, tt.order))
+			values, err := Decode[float32](tt.registers, tt.order)
+			assert.NoError(t, err)
+			assert.Equal(t, []float32{123.456}, values)
+		})
+	}
+}
+
+func TestUint64(t *testing.T) {
+	tests := []struct {
+		order     ByteOrder
+		registers []uint16
+	}{
+		{order: ABCD, registers: []uint16{0x0102, 0x0304, 0x0506, 0x0708}},
+		{order: CDAB, registers: []uint16{0x0708, 0x0506, 0x0304, 0x0102}},
+		{order: BADC, registers: []uint16{0x0201, 0x0403, 0x0605, 0x0807}},
+		{order: DCBA, registers: []uint16{0x0807, 0x0605, 0x0403, 0x0201}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			assert.Equal(t, tt.registers, Encode([]uint64{0x0102030405060708}, tt.order))
+			values, err := Decode[uint64](tt.registers, tt.order)
+			assert.NoError(t, err)
+			assert.Equal(t, []uint64{0x0102030405060708}, values)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, order := range []ByteOrder{ABCD, CDAB, BADC, DCBA} {
+		int32s := []int32{0, -1, math.MinInt32, math.MaxInt32, 123456}
+		decodedInt32s, err := Decode[int32](Encode(int32s, order), order)
+		assert.NoError(t, err)
+		assert.Equal(t, int32s, decodedInt32s)
+
+		uint32s := []uint32{0, math.MaxUint32, 0xDEADBEEF}
+		decodedUint32s, err := Decode[uint32](Encode(uint32s, order), order)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32s, decodedUint32s)
+
+		int64s := []int64{0, -1, math.MinInt64, math.MaxInt64}
+		decodedInt64s, err := Decode[int64](Encode(int64s, order), order)
+		assert.NoError(t, err)
+		assert.Equal(t, int64s, decodedInt64s)
+
+		float64s := []float64{0, 1, -2.5, math.Pi, math.Inf(1)}
+		decodedFloat64s, err := Decode[float64](Encode(float64s, order), order)
+		assert.NoError(t, err)
+		assert.Equal(t, float64s, decodedFloat64s)
+	}
+	assert.Equal(t, []uint16{0x3FF0, 0x0000, 0x0000, 0x0000}, Encode([]float64{1}, ABCD))
+	assert.Equal(t, []uint16{0xFFFF, 0xFFFE}, Encode([]int32{-2}, ABCD))
+}
+
+func TestDecodeInvalidLength(t *testing.T) {
+	_, err := Decode[float32]([]uint16{0x0001, 0x0002, 0x0003}, ABCD)
+	assert.ErrorIs(t, err, common.ErrInvalidLength)
+	_, err = Decode[float64]([]uint16{0x0001, 0x0002}, ABCD)
+	assert.ErrorIs(t, err, common.ErrInvalidLength)
+}
+
+func TestString(t *testing.T) {
+	registers, err := EncodeString("Hello", 4, ABCD)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{0x4865, 0x6C6C, 0x6F00, 0x0000}, registers)
+	assert.Equal(t, "Hello", DecodeString(registers, ABCD))
+
+	registers, err = EncodeString("Hello", 3, BADC)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{0x6548, 0x6C6C, 0x006F}, registers)
+	assert.Equal(t, "Hello", DecodeString(registers, DCBA))
+
+	_, err = EncodeString("Hello", 2, ABCD)
+	assert.ErrorIs(t, err, common.ErrInvalidLength)
+}
+
+func TestParseByteOrder(t *testing.T) {
+	order, err := ParseByteOrder("cdab")
+	assert.NoError(t, err)
+	assert.Equal(t, CDAB, order)
+	_, err = ParseByteOrder("ACBD")
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+}
@@ -8,11 +8,13 @@ var (
 	ErrInvalidLength                      = errors.New("invalid length")
 	ErrWrittenLengthDoesNotMatch          = errors.New("written length does not match")
 	ErrUnknownFunctionCode                = errors.New("unknown function code")
+	ErrUnknownOperation                   = errors.New("unknown operation type")
 	ErrShortWrite                         = errors.New("short write")
 	ErrTimeout                            = errors.New("timeout")
 	ErrIgnorePacket                       = errors.New("ignore packet")
 	ErrNotOurAddress                      = errors.New("not our address")
 	ErrUnsupportedFunctionCode            = errors.New("unsupported function code")
+	ErrFunctionCodeRegistered             = errors.New("function code already registered")
 	ErrInvalidFunctionCode                = errors.New("invalid function code")
 	ErrInvalidData                        = errors.New("invalid data")
 	ErrInvalidAddress                     = errors.New("invalid address")
@@ -20,6 +22,8 @@ var (
 	ErrInvalidValue                       = errors.New("invalid value")
 	ErrResponseValueMismatch              = errors.New("response value mismatch")
 	ErrResponseOffsetMismatch             = errors.New("response offset mismatch")
+	ErrResponseUnitIDMismatch             = errors.New("response unit id mismatch")
+	ErrBroadcastNotSupported              = errors.New("function can't be broadcast")
 	ErrNotImplemented                     = errors.New("not implemented")
 	ErrIllegalFunction                    = errors.New("illegal function")
 	ErrIllegalDataAddress                 = errors.New("illegal data address")
@@ -36,10 +40,25 @@ var (
 	ErrMissingValue                       = errors.New("missing value")
 	ErrTransportClosing                   = errors.New("transport is closing")
 	ErrTransportRequired                  = errors.New("transport is required")
+	ErrPollerClosed                       = errors.New("poller is closed")
+	ErrNotConnected                       = errors.New("not connected")
 	ErrHandlerRequired                    = errors.New("handler is required")
 	ErrInvalidHeader                      = errors.New("invalid header")
+	ErrInvalidTag                         = errors.New("invalid modbus tag")
+	ErrInvalidProtocolID                  = errors.New("invalid protocol id")
 	ErrInvalidBaudRate                    = errors.New("invalid baud rate")
 	ErrInvalidDataBits                    = errors.New("invalid data bits")
 	ErrInvalidParity                      = errors.New("invalid parity")
 	ErrInvalidStopBits                    = errors.New("invalid stop bits")
 )
+
+// RetryableErrors maps the names accepted by the retryOn URI parameter to the errors they retry.
+var RetryableErrors = map[string]error{
+	"timeout":                ErrTimeout,
+	"checksum":               ErrInvalidChecksum,
+	"busy":                   ErrServerDeviceBusy,
+	"acknowledge":            ErrAcknowledge,
+	"gatewayPathUnavailable": ErrGatewayPathUnavailable,
+	"gatewayTargetFailed":    ErrGatewayTargetDeviceFailedToRespond,
+	"notConnected":           ErrNotConnected,
+}
@@ -1,30 +1,58 @@
 package data
 
 import (
+	"fmt"
+
 	"github.com/rinzlerlabs/gomodbus/common"
 	"go.uber.org/zap/zapcore"
 )
 
 type FunctionCode byte
 type ExceptionCode byte
+type MEIType byte
+type DeviceIDCode byte
+type DeviceObjectID byte
+type DiagnosticSubFunction uint16
 
 const (
-	ReadCoils                   FunctionCode = 0x01
-	ReadDiscreteInputs          FunctionCode = 0x02
-	ReadHoldingRegisters        FunctionCode = 0x03
-	ReadInputRegisters          FunctionCode = 0x04
-	WriteSingleCoil             FunctionCode = 0x05
-	WriteSingleRegister         FunctionCode = 0x06
-	WriteMultipleCoils          FunctionCode = 0x0F
-	WriteMultipleRegisters      FunctionCode = 0x10
-	ReadCoilsError              FunctionCode = 0x81
-	ReadDiscreteInputsError     FunctionCode = 0x82
-	ReadHoldingRegistersError   FunctionCode = 0x83
-	ReadInputRegistersError     FunctionCode = 0x84
-	WriteSingleCoilError        FunctionCode = 0x85
-	WriteSingleRegisterError    FunctionCode = 0x86
-	WriteMultipleCoilsError     FunctionCode = 0x8F
-	WriteMultipleRegistersError FunctionCode = 0x90
+	ReadCoils                           FunctionCode = 0x01
+	ReadDiscreteInputs                  FunctionCode = 0x02
+	ReadHoldingRegisters                FunctionCode = 0x03
+	ReadInputRegisters                  FunctionCode = 0x04
+	WriteSingleCoil                     FunctionCode = 0x05
+	WriteSingleRegister                 FunctionCode = 0x06
+	ReadExceptionStatus                 FunctionCode = 0x07
+	Diagnostics                         FunctionCode = 0x08
+	GetCommEventCounter                 FunctionCode = 0x0B
+	GetCommEventLog                     FunctionCode = 0x0C
+	WriteMultipleCoils                  FunctionCode = 0x0F
+	WriteMultipleRegisters              FunctionCode = 0x10
+	ReportServerID                      FunctionCode = 0x11
+	ReadFileRecord                      FunctionCode = 0x14
+	WriteFileRecord                     FunctionCode = 0x15
+	MaskWriteRegister                   FunctionCode = 0x16
+	ReadWriteMultipleRegisters          FunctionCode = 0x17
+	ReadFIFOQueue                       FunctionCode = 0x18
+	EncapsulatedInterfaceTransport      FunctionCode = 0x2B
+	ReadCoilsError                      FunctionCode = 0x81
+	ReadDiscreteInputsError             FunctionCode = 0x82
+	ReadHoldingRegistersError           FunctionCode = 0x83
+	ReadInputRegistersError             FunctionCode = 0x84
+	WriteSingleCoilError                FunctionCode = 0x85
+	WriteSingleRegisterError            FunctionCode = 0x86
+	ReadExceptionStatusError            FunctionCode = 0x87
+	DiagnosticsError                    FunctionCode = 0x88
+	GetCommEventCounterError            FunctionCode = 0x8B
+	GetCommEventLogError                FunctionCode = 0x8C
+	WriteMultipleCoilsError             FunctionCode = 0x8F
+	WriteMultipleRegistersError         FunctionCode = 0x90
+	ReportServerIDError                 FunctionCode = 0x91
+	ReadFileRecordError                 FunctionCode = 0x94
+	WriteFileRecordError                FunctionCode = 0x95
+	MaskWriteRegisterError              FunctionCode = 0x96
+	ReadWriteMultipleRegistersError     FunctionCode = 0x97
+	ReadFIFOQueueError                  FunctionCode = 0x98
+	EncapsulatedInterfaceTransportError FunctionCode = 0xAB
 
 	IllegalFunction                    ExceptionCode = 0x01
 	IllegalDataAddress                 ExceptionCode = 0x02
@@ -35,6 +63,32 @@ const (
 	MemoryParityError                  ExceptionCode = 0x08
 	GatewayPathUnavailable             ExceptionCode = 0x0A
 	GatewayTargetDeviceFailedToRespond ExceptionCode = 0x0B
+
+	ReadDeviceIdentificationMEI MEIType = 0x0E
+
+	BasicDeviceIdentification    DeviceIDCode = 0x01
+	RegularDeviceIdentification  DeviceIDCode = 0x02
+	ExtendedDeviceIdentification DeviceIDCode = 0x03
+	SpecificDeviceIdentification DeviceIDCode = 0x04
+
+	VendorName          DeviceObjectID = 0x00
+	ProductCode         DeviceObjectID = 0x01
+	MajorMinorRevision  DeviceObjectID = 0x02
+	VendorURL           DeviceObjectID = 0x03
+	ProductName         DeviceObjectID = 0x04
+	ModelName           DeviceObjectID = 0x05
+	UserApplicationName DeviceObjectID = 0x06
+
+	ReturnQueryData                    DiagnosticSubFunction = 0x00
+	RestartCommunicationsOption        DiagnosticSubFunction = 0x01
+	ReturnDiagnosticRegister           DiagnosticSubFunction = 0x02
+	ForceListenOnlyMode                DiagnosticSubFunction = 0x04
+	ClearCountersAndDiagnosticRegister DiagnosticSubFunction = 0x0A
+	ReturnBusMessageCount              DiagnosticSubFunction = 0x0B
+	ReturnBusCommunicationErrorCount   DiagnosticSubFunction = 0x0C
+	ReturnBusExceptionErrorCount       DiagnosticSubFunction = 0x0D
+	ReturnServerMessageCount           DiagnosticSubFunction = 0x0E
+	ReturnServerNoResponseCount        DiagnosticSubFunction = 0x0F
 )
 
 func (f FunctionCode) String() string {
@@ -51,17 +105,39 @@ func (f FunctionCode) String() string {
 		return "WriteSingleCoil"
 	case WriteSingleRegister:
 		return "WriteSingleRegister"
+	case ReadExceptionStatus:
+		return "ReadExceptionStatus"
+	case Diagnostics:
+		return "Diagnostics"
+	case GetCommEventCounter:
+		return "GetCommEventCounter"
+	case GetCommEventLog:
+		return "GetCommEventLog"
 	case WriteMultipleCoils:
 		return "WriteMultipleCoils"
 	case WriteMultipleRegisters:
 		return "WriteMultipleRegisters"
+	case ReportServerID:
+		return "ReportServerID"
+	case ReadFileRecord:
+		return "ReadFileRecord"
+	case WriteFileRecord:
+		return "WriteFileRecord"
+	case MaskWriteRegister:
+		return "MaskWriteRegister"
+	case ReadWriteMultipleRegisters:
+		return "ReadWriteMultipleRegisters"
+	case ReadFIFOQueue:
+		return "ReadFIFOQueue"
+	case EncapsulatedInterfaceTransport:
+		return "EncapsulatedInterfaceTransport"
 	default:
 		return "Unknown"
 	}
 }
 
 func (f FunctionCode) IsException() bool {
-	return f >= 0x80 && f <= 0x91
+	return f >= 0x80
 }
 
 func (f ExceptionCode) String() string {
@@ -89,6 +165,54 @@ func (f ExceptionCode) String() string {
 	}
 }
 
+func (o DeviceObjectID) String() string {
+	switch o {
+	case VendorName:
+		return "VendorName"
+	case ProductCode:
+		return "ProductCode"
+	case MajorMinorRevision:
+		return "MajorMinorRevision"
+	case VendorURL:
+		return "VendorURL"
+	case ProductName:
+		return "ProductName"
+	case ModelName:
+		return "ModelName"
+	case UserApplicationName:
+		return "UserApplicationName"
+	default:
+		return fmt.Sprintf("Object%02X", byte(o))
+	}
+}
+
+func (s DiagnosticSubFunction) String() string {
+	switch s {
+	case ReturnQueryData:
+		return "ReturnQueryData"
+	case RestartCommunicationsOption:
+		return "RestartCommunicationsOption"
+	case ReturnDiagnosticRegister:
+		return "ReturnDiagnosticRegister"
+	case ForceListenOnlyMode:
+		return "ForceListenOnlyMode"
+	case ClearCountersAndDiagnosticRegister:
+		return "ClearCountersAndDiagnosticRegister"
+	case ReturnBusMessageCount:
+		return "ReturnBusMessageCount"
+	case ReturnBusCommunicationErrorCount:
+		return "ReturnBusCommunicationErrorCount"
+	case ReturnBusExceptionErrorCount:
+		return "ReturnBusExceptionErrorCount"
+	case ReturnServerMessageCount:
+		return "ReturnServerMessageCount"
+	case ReturnServerNoResponseCount:
+		return "ReturnServerNoResponseCount"
+	default:
+		return "Unknown"
+	}
+}
+
 type ModbusOperation interface {
 	zapcore.ObjectMarshaler
 }
@@ -122,8 +246,37 @@ func (e ModbusOperationException) MarshalLogObject(encoder zapcore.ObjectEncoder
 	return nil
 }
 
-func (e *ModbusOperationException) Error() error {
-	switch e.ExceptionCode {
+// Exception creates the error returned to the client for this exception response, address is the serial address or TCP
+// unit ID of the device that sent it.
+func (e *ModbusOperationException) Exception(address uint16) *ModbusException {
+	return &ModbusException{
+		FunctionCode:  e.FunctionCode &^ 0x80,
+		ExceptionCode: e.ExceptionCode,
+		Address:       address,
+	}
+}
+
+// ModbusException is the error returned when a device answers a request with an exception response. It can be matched
+// with errors.As, and with errors.Is against the common error for its exception code, e.g. common.ErrServerDeviceBusy.
+type ModbusException struct {
+	// FunctionCode is the function code of the request, without the exception bit
+	FunctionCode  FunctionCode
+	ExceptionCode ExceptionCode
+	// Address is the serial address or TCP unit ID of the device that sent the exception
+	Address uint16
+}
+
+func (e *ModbusException) Error() string {
+	return fmt.Sprintf("%s: device %d, function 0x%02X", e.Unwrap().Error(), e.Address, byte(e.FunctionCode))
+}
+
+func (e *ModbusException) Unwrap() error {
+	return e.ExceptionCode.Err()
+}
+
+// Err returns the common error for the exception code.
+func (c ExceptionCode) Err() error {
+	switch c {
 	case IllegalFunction:
 		return common.ErrIllegalFunction
 	case IllegalDataAddress:
@@ -147,8 +300,147 @@ func (e *ModbusOperationException) Error() error {
 	}
 }
 
-func ModbusOperationToBytes(operation ModbusOperation) []byte {
-	if op, ok := operation.(ModbusWriteArrayRequest[[]bool]); ok {
+func ModbusOperationToBytes(operation ModbusOperation) ([]byte, error) {
+	if op, ok := operation.(ModbusCustomOperation); ok {
+		return op.Bytes(), nil
+	} else if _, ok := operation.(*ReadExceptionStatusRequest); ok {
+		return []byte{}, nil
+	} else if _, ok := operation.(*GetCommEventCounterRequest); ok {
+		return []byte{}, nil
+	} else if _, ok := operation.(*GetCommEventLogRequest); ok {
+		return []byte{}, nil
+	} else if _, ok := operation.(*ReportServerIDRequest); ok {
+		return []byte{}, nil
+	} else if op, ok := operation.(ModbusReadFileRecordRequest); ok {
+		data := []byte{byte(7 * len(op.SubRequests()))}
+		for _, s := range op.SubRequests() {
+			data = append(data,
+				FileRecordReferenceType,
+				byte(s.FileNumber>>8),
+				byte(s.FileNumber),
+				byte(s.RecordNumber>>8),
+				byte(s.RecordNumber),
+				byte(s.RecordLength>>8),
+				byte(s.RecordLength),
+			)
+		}
+		return data, nil
+	} else if op, ok := operation.(ModbusReadFileRecordResponse); ok {
+		data := []byte{0x00}
+		for _, r := range op.Records() {
+			data = append(data, byte(1+2*len(r)), FileRecordReferenceType)
+			for _, v := range r {
+				data = append(data, byte(v>>8), byte(v))
+			}
+		}
+		data[0] = byte(len(data) - 1)
+		return data, nil
+	} else if op, ok := operation.(ModbusWriteFileRecordRequest); ok {
+		// The response is an echo of the request, so this covers both
+		data := []byte{0x00}
+		for _, r := range op.FileRecords() {
+			data = append(data,
+				FileRecordReferenceType,
+				byte(r.FileNumber>>8),
+				byte(r.FileNumber),
+				byte(r.RecordNumber>>8),
+				byte(r.RecordNumber),
+				byte(len(r.Values)>>8),
+				byte(len(r.Values)),
+			)
+			for _, v := range r.Values {
+				data = append(data, byte(v>>8), byte(v))
+			}
+		}
+		data[0] = byte(len(data) - 1)
+		return data, nil
+	} else if op, ok := operation.(ModbusReadFIFOQueueRequest); ok {
+		return []byte{byte(op.Pointer() >> 8), byte(op.Pointer())}, nil
+	} else if op, ok := operation.(ModbusReadFIFOQueueResponse); ok {
+		byteCount := 2 + 2*len(op.Queue())
+		data := []byte{
+			byte(byteCount >> 8),
+			byte(byteCount),
+			byte(len(op.Queue()) >> 8),
+			byte(len(op.Queue())),
+		}
+		for _, v := range op.Queue() {
+			data = append(data, byte(v>>8), byte(v))
+		}
+		return data, nil
+	} else if op, ok := operation.(ModbusReportServerIDResponse); ok {
+		data := append([]byte{byte(len(op.ServerID()) + 1)}, op.ServerID()...)
+		if op.RunIndicator() {
+			return append(data, 0xFF), nil
+		}
+		return append(data, 0x00), nil
+	} else if op, ok := operation.(ModbusReadExceptionStatusResponse); ok {
+		return []byte{op.ExceptionStatus()}, nil
+	} else if op, ok := operation.(ModbusGetCommEventLogResponse); ok {
+		// The log response satisfies the counter response, so it has to be checked first
+		data := []byte{
+			byte(6 + len(op.Events())),
+			byte(op.Status() >> 8),
+			byte(op.Status()),
+			byte(op.EventCount() >> 8),
+			byte(op.EventCount()),
+			byte(op.MessageCount() >> 8),
+			byte(op.MessageCount()),
+		}
+		return append(data, op.Events()...), nil
+	} else if op, ok := operation.(ModbusGetCommEventCounterResponse); ok {
+		return []byte{
+			byte(op.Status() >> 8),
+			byte(op.Status()),
+			byte(op.EventCount() >> 8),
+			byte(op.EventCount()),
+		}, nil
+	} else if op, ok := operation.(ModbusDiagnosticsRequest); ok {
+		return append([]byte{byte(op.SubFunction() >> 8), byte(op.SubFunction())}, op.Data()...), nil
+	} else if op, ok := operation.(ModbusDiagnosticsResponse); ok {
+		return append([]byte{byte(op.SubFunction() >> 8), byte(op.SubFunction())}, op.Data()...), nil
+	} else if op, ok := operation.(ModbusReadDeviceIdentificationRequest); ok {
+		return []byte{
+			byte(ReadDeviceIdentificationMEI),
+			byte(op.DeviceIDCode()),
+			byte(op.ObjectID()),
+		}, nil
+	} else if op, ok := operation.(ModbusReadDeviceIdentificationResponse); ok {
+		data := []byte{
+			byte(ReadDeviceIdentificationMEI),
+			byte(op.DeviceIDCode()),
+			op.ConformityLevel(),
+			0x00,
+			byte(op.NextObjectID()),
+			byte(len(op.Objects())),
+		}
+		if op.MoreFollows() {
+			data[3] = 0xFF
+		}
+		for _, o := range op.Objects() {
+			data = append(data, byte(o.ID), byte(len(o.Value)))
+			data = append(data, o.Value...)
+		}
+		return data, nil
+	} else if op, ok := operation.(ModbusReadWriteArrayRequest); ok {
+		valueCount := len(op.Values())
+		byteCount := 2 * valueCount
+		data := make([]byte, 9+byteCount)
+		data[0] = byte(op.ReadOffset() >> 8)
+		data[1] = byte(op.ReadOffset())
+		data[2] = byte(op.Count() >> 8)
+		data[3] = byte(op.Count())
+		data[4] = byte(op.WriteOffset() >> 8)
+		data[5] = byte(op.WriteOffset())
+		data[6] = byte(valueCount >> 8)
+		data[7] = byte(valueCount)
+		data[8] = byte(byteCount)
+		for i, v := range op.Values() {
+			data[9+i*2] = byte(v >> 8)
+			data[10+i*2] = byte(v)
+		}
+		return data, nil
+	} else if op, ok := operation.(ModbusWriteArrayRequest[[]bool]); ok {
 		valueCount := len(op.Values())
 		byteCount := getReturnByteCount(op.Values())
 		data := make([]byte, 5+byteCount)
@@ -162,7 +454,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 				data[5+i/8] |= 1 << uint(i%8)
 			}
 		}
-		return data
+		return data, nil
 	} else if op, ok := operation.(ModbusWriteArrayRequest[[]uint16]); ok {
 		valueCount := len(op.Values())
 		byteCount := 2 * valueCount
@@ -176,7 +468,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			data[5+i*2] = byte(v >> 8)
 			data[6+i*2] = byte(v)
 		}
-		return data
+		return data, nil
 	} else if op, ok := operation.(ModbusWriteSingleRequest[bool]); ok {
 		var valBytes []byte
 		if op.Value() {
@@ -189,7 +481,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteSingleRequest[uint16]); ok {
 		val := op.Value()
 		valBytes := []byte{
@@ -201,7 +493,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteSingleResponse[bool]); ok {
 		var valBytes []byte
 		if op.Value() {
@@ -214,7 +506,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteSingleResponse[uint16]); ok {
 		val := op.Value()
 		valBytes := []byte{
@@ -226,21 +518,39 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
+	} else if op, ok := operation.(ModbusMaskWriteRequest); ok {
+		return []byte{
+			byte(op.Offset() >> 8),
+			byte(op.Offset()),
+			byte(op.AndMask() >> 8),
+			byte(op.AndMask()),
+			byte(op.OrMask() >> 8),
+			byte(op.OrMask()),
+		}, nil
+	} else if op, ok := operation.(ModbusMaskWriteResponse); ok {
+		return []byte{
+			byte(op.Offset() >> 8),
+			byte(op.Offset()),
+			byte(op.AndMask() >> 8),
+			byte(op.AndMask()),
+			byte(op.OrMask() >> 8),
+			byte(op.OrMask()),
+		}, nil
 	} else if op, ok := operation.(ModbusWriteArrayResponse[[]uint16]); ok {
 		return []byte{
 			byte(op.Offset() >> 8),
 			byte(op.Offset()),
 			byte(op.Count() >> 8),
 			byte(op.Count()),
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteArrayResponse[[]bool]); ok {
 		return []byte{
 			byte(op.Offset() >> 8),
 			byte(op.Offset()),
 			byte(op.Count() >> 8),
 			byte(op.Count()),
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteSingleResponse[bool]); ok {
 		var valBytes []byte
 		if op.Value() {
@@ -253,7 +563,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusWriteSingleResponse[uint16]); ok {
 		val := op.Value()
 		valBytes := []byte{
@@ -265,7 +575,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			byte(op.Offset()),
 			valBytes[0],
 			valBytes[1],
-		}
+		}, nil
 	} else if op, ok := operation.(ModbusReadResponse[[]uint16]); ok {
 		length := 2 * len(op.Values())
 		data := make([]byte, 1+length)
@@ -274,7 +584,7 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 			data[1+i*2] = byte(v >> 8)
 			data[2+i*2] = byte(v)
 		}
-		return data
+		return data, nil
 	} else if op, ok := operation.(ModbusReadResponse[[]bool]); ok {
 		length := getReturnByteCount(op.Values())
 		data := make([]byte, 1+length)
@@ -284,17 +594,17 @@ func ModbusOperationToBytes(operation ModbusOperation) []byte {
 				data[1+i/8] |= 1 << uint(i%8)
 			}
 		}
-		return data
+		return data, nil
 	} else if op, ok := operation.(ModbusReadRequest); ok {
 		return []byte{
 			byte(op.Offset() >> 8),
 			byte(op.Offset()),
 			byte(op.Count() >> 8),
 			byte(op.Count()),
-		}
+		}, nil
 	} else if op, ok := operation.(*ModbusOperationException); ok {
-		return []byte{byte(op.ExceptionCode)}
+		return []byte{byte(op.ExceptionCode)}, nil
 	} else {
-		panic("Unknown operation type")
+		return nil, common.ErrUnknownOperation
 	}
 }
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModbusCustomOperation is a request or response for a function code registered with RegisterCustomFunction. The library
+// knows nothing about the layout of the data, so the operation provides its own function code and encoding.
+type ModbusCustomOperation interface {
+	ModbusOperation
+	FunctionCode() FunctionCode
+	// Bytes returns the data of the PDU, not including the function code.
+	Bytes() []byte
+}
+
+// FrameLengthFunc works out how long the data of a PDU is, not including the function code, from the data read so far.
+// It is called with no data first, and if it can't tell the length yet it returns the number of bytes it needs to see
+// and false, it is then called again once those bytes have been read. Serial RTU is the only transport that needs it,
+// the other transports frame their messages themselves.
+type FrameLengthFunc func(data []byte) (length int, ok bool)
+
+// FixedFrameLength is a FrameLengthFunc for functions whose data is always n bytes long.
+func FixedFrameLength(n int) FrameLengthFunc {
+	return func([]byte) (int, bool) {
+		return n, true
+	}
+}
+
+// ByteCountFrameLength is a FrameLengthFunc for functions whose data starts with a byte count followed by that many
+// bytes, like the response to Read Holding Registers.
+func ByteCountFrameLength(data []byte) (int, bool) {
+	if len(data) < 1 {
+		return 1, false
+	}
+	return 1 + int(data[0]), true
+}
+
+// CustomFunction describes a function code the library doesn't implement, like the user defined codes 65 to 72 and
+// 100 to 110 or a vendor specific code, so it can be sent by the client and answered by the server.
+type CustomFunction struct {
+	FunctionCode FunctionCode
+	// ParseRequest decodes the data of a request, when it is nil the request is decoded into a CustomOperation
+	ParseRequest func(data []byte) (ModbusCustomOperation, error)
+	// ParseResponse decodes the data of a response, when it is nil the response is decoded into a CustomOperation
+	ParseResponse func(data []byte) (ModbusCustomOperation, error)
+	// RequestLength frames requests on serial RTU lines, servers ignore the request when it is nil
+	RequestLength FrameLengthFunc
+	// ResponseLength frames responses on serial RTU lines, clients fail with ErrUnsupportedFunctionCode when it is nil
+	ResponseLength FrameLengthFunc
+	// Handler answers requests on the server, returning ErrIllegalFunction, ErrIllegalDataAddress or ErrIllegalDataValue
+	// sends the matching exception and a nil response sends Server Device Failure. When it is nil the server answers
+	// with an Illegal Function exception.
+	Handler func(request ModbusCustomOperation) (ModbusCustomOperation, error)
+}
+
+var (
+	customFunctionsMu sync.RWMutex
+	customFunctions   = make(map[FunctionCode]CustomFunction)
+)
+
+// RegisterCustomFunction makes a custom function code available to every client, server and transport in the process.
+// The function code must be between 1 and 127 and can't be one of the codes the library already implements, or
+// already be registered.
+func RegisterCustomFunction(function CustomFunction) error {
+	if function.FunctionCode == 0 || function.FunctionCode.IsException() || function.FunctionCode.String() != "Unknown" {
+		return common.ErrInvalidFunctionCode
+	}
+	customFunctionsMu.Lock()
+	defer customFunctionsMu.Unlock()
+	if _, ok := customFunctions[function.FunctionCode]; ok {
+		return common.ErrFunctionCodeRegistered
+	}
+	customFunctions[function.FunctionCode] = function
+	return nil
+}
+
+// UnregisterCustomFunction removes a custom function code, it is a no-op if the code isn't registered.
+func UnregisterCustomFunction(functionCode FunctionCode) {
+	customFunctionsMu.Lock()
+	defer customFunctionsMu.Unlock()
+	delete(customFunctions, functionCode)
+}
+
+// LookupCustomFunction returns the custom function registered for a function code, if there is one.
+func LookupCustomFunction(functionCode FunctionCode) (CustomFunction, bool) {
+	customFunctionsMu.RLock()
+	defer customFunctionsMu.RUnlock()
+	function, ok := customFunctions[functionCode]
+	return function, ok
+}
+
+func (f CustomFunction) parseRequest(bytes []byte) (ModbusOperation, error) {
+	if f.ParseRequest == nil {
+		return NewCustomOperation(f.FunctionCode, bytes), nil
+	}
+	return f.ParseRequest(bytes)
+}
+
+func (f CustomFunction) parseResponse(bytes []byte) (ModbusOperation, error) {
+	if f.ParseResponse == nil {
+		return NewCustomOperation(f.FunctionCode, bytes), nil
+	}
+	return f.ParseResponse(bytes)
+}
+
+// NewCustomOperation creates a request or response for a custom function code that carries its data as raw bytes.
+func NewCustomOperation(functionCode FunctionCode, data []byte) *CustomOperation {
+	return &CustomOperation{
+		functionCode: functionCode,
+		data:         append([]byte{}, data...),
+	}
+}
+
+type CustomOperation struct {
+	ModbusCustomOperation
+	functionCode FunctionCode
+	data         []byte
+}
+
+func (o CustomOperation) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint8("FunctionCode", uint8(o.functionCode))
+	encoder.AddString("Data", common.EncodeToString(o.data))
+	return nil
+}
+
+func (o CustomOperation) FunctionCode() FunctionCode {
+	return o.functionCode
+}
+
+func (o CustomOperation) Bytes() []byte {
+	return o.data
+}
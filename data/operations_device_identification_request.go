@@ -0,0 +1,36 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+type ModbusReadDeviceIdentificationRequest interface {
+	ModbusOperation
+	DeviceIDCode() DeviceIDCode
+	ObjectID() DeviceObjectID
+}
+
+func NewReadDeviceIdentificationRequest(code DeviceIDCode, objectID DeviceObjectID) *ReadDeviceIdentificationRequest {
+	return &ReadDeviceIdentificationRequest{
+		code:     code,
+		objectID: objectID,
+	}
+}
+
+type ReadDeviceIdentificationRequest struct {
+	ModbusReadDeviceIdentificationRequest
+	code     DeviceIDCode
+	objectID DeviceObjectID
+}
+
+func (r ReadDeviceIdentificationRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint8("DeviceIDCode", uint8(r.code))
+	encoder.AddString("ObjectID", r.objectID.String())
+	return nil
+}
+
+func (r ReadDeviceIdentificationRequest) DeviceIDCode() DeviceIDCode {
+	return r.code
+}
+
+func (r ReadDeviceIdentificationRequest) ObjectID() DeviceObjectID {
+	return r.objectID
+}
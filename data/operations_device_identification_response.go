@@ -0,0 +1,71 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+// DeviceIdentificationObject is a single object returned by a Read Device Identification request.
+type DeviceIdentificationObject struct {
+	ID    DeviceObjectID
+	Value []byte
+}
+
+type ModbusReadDeviceIdentificationResponse interface {
+	ModbusOperation
+	DeviceIDCode() DeviceIDCode
+	ConformityLevel() byte
+	MoreFollows() bool
+	NextObjectID() DeviceObjectID
+	Objects() []DeviceIdentificationObject
+}
+
+func NewReadDeviceIdentificationResponse(code DeviceIDCode, conformityLevel byte, moreFollows bool, nextObjectID DeviceObjectID, objects []DeviceIdentificationObject) *ReadDeviceIdentificationResponse {
+	return &ReadDeviceIdentificationResponse{
+		code:            code,
+		conformityLevel: conformityLevel,
+		moreFollows:     moreFollows,
+		nextObjectID:    nextObjectID,
+		objects:         objects,
+	}
+}
+
+type ReadDeviceIdentificationResponse struct {
+	ModbusReadDeviceIdentificationResponse
+	code            DeviceIDCode
+	conformityLevel byte
+	moreFollows     bool
+	nextObjectID    DeviceObjectID
+	objects         []DeviceIdentificationObject
+}
+
+func (r ReadDeviceIdentificationResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint8("DeviceIDCode", uint8(r.code))
+	encoder.AddUint8("ConformityLevel", r.conformityLevel)
+	encoder.AddBool("MoreFollows", r.moreFollows)
+	encoder.AddString("NextObjectID", r.nextObjectID.String())
+	encoder.AddObject("Objects", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, o := range r.objects {
+			enc.AddString(o.ID.String(), string(o.Value))
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadDeviceIdentificationResponse) DeviceIDCode() DeviceIDCode {
+	return r.code
+}
+
+func (r ReadDeviceIdentificationResponse) ConformityLevel() byte {
+	return r.conformityLevel
+}
+
+func (r ReadDeviceIdentificationResponse) MoreFollows() bool {
+	return r.moreFollows
+}
+
+func (r ReadDeviceIdentificationResponse) NextObjectID() DeviceObjectID {
+	return r.nextObjectID
+}
+
+func (r ReadDeviceIdentificationResponse) Objects() []DeviceIdentificationObject {
+	return r.objects
+}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"encoding/hex"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type ModbusDiagnosticsRequest interface {
+	ModbusOperation
+	SubFunction() DiagnosticSubFunction
+	Data() []byte
+}
+
+func NewDiagnosticsRequest(subFunction DiagnosticSubFunction, data []byte) *DiagnosticsRequest {
+	return &DiagnosticsRequest{
+		subFunction: subFunction,
+		data:        data,
+	}
+}
+
+type DiagnosticsRequest struct {
+	ModbusDiagnosticsRequest
+	subFunction DiagnosticSubFunction
+	data        []byte
+}
+
+func (r DiagnosticsRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("SubFunction", r.subFunction.String())
+	encoder.AddString("Data", hex.EncodeToString(r.data))
+	return nil
+}
+
+func (r DiagnosticsRequest) SubFunction() DiagnosticSubFunction {
+	return r.subFunction
+}
+
+func (r DiagnosticsRequest) Data() []byte {
+	return r.data
+}
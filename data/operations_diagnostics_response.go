@@ -0,0 +1,40 @@
+package data
+
+import (
+	"encoding/hex"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type ModbusDiagnosticsResponse interface {
+	ModbusOperation
+	SubFunction() DiagnosticSubFunction
+	Data() []byte
+}
+
+func NewDiagnosticsResponse(subFunction DiagnosticSubFunction, data []byte) *DiagnosticsResponse {
+	return &DiagnosticsResponse{
+		subFunction: subFunction,
+		data:        data,
+	}
+}
+
+type DiagnosticsResponse struct {
+	ModbusDiagnosticsResponse
+	subFunction DiagnosticSubFunction
+	data        []byte
+}
+
+func (r DiagnosticsResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("SubFunction", r.subFunction.String())
+	encoder.AddString("Data", hex.EncodeToString(r.data))
+	return nil
+}
+
+func (r DiagnosticsResponse) SubFunction() DiagnosticSubFunction {
+	return r.subFunction
+}
+
+func (r DiagnosticsResponse) Data() []byte {
+	return r.data
+}
@@ -0,0 +1,28 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+type ModbusReadFIFOQueueRequest interface {
+	ModbusOperation
+	Pointer() uint16
+}
+
+func NewReadFIFOQueueRequest(pointer uint16) *ReadFIFOQueueRequest {
+	return &ReadFIFOQueueRequest{
+		pointer: pointer,
+	}
+}
+
+type ReadFIFOQueueRequest struct {
+	ModbusReadFIFOQueueRequest
+	pointer uint16
+}
+
+func (r ReadFIFOQueueRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("Pointer", r.pointer)
+	return nil
+}
+
+func (r ReadFIFOQueueRequest) Pointer() uint16 {
+	return r.pointer
+}
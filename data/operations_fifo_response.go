@@ -0,0 +1,36 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+// MaxFIFOCount is the most values a FIFO queue can return.
+const MaxFIFOCount = 31
+
+type ModbusReadFIFOQueueResponse interface {
+	ModbusOperation
+	Queue() []uint16
+}
+
+func NewReadFIFOQueueResponse(queue []uint16) *ReadFIFOQueueResponse {
+	return &ReadFIFOQueueResponse{
+		queue: queue,
+	}
+}
+
+type ReadFIFOQueueResponse struct {
+	ModbusReadFIFOQueueResponse
+	queue []uint16
+}
+
+func (r ReadFIFOQueueResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddArray("Queue", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, v := range r.queue {
+			enc.AppendUint16(v)
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadFIFOQueueResponse) Queue() []uint16 {
+	return r.queue
+}
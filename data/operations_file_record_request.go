@@ -0,0 +1,99 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+// FileRecordReferenceType is the only reference type the spec allows in file record sub-requests.
+const FileRecordReferenceType byte = 0x06
+
+// FileSubRequest is a single sub-request of a Read File Record request.
+type FileSubRequest struct {
+	FileNumber   uint16
+	RecordNumber uint16
+	RecordLength uint16
+}
+
+// FileRecord is a single sub-request of a Write File Record request, the record length is the number of values.
+type FileRecord struct {
+	FileNumber   uint16
+	RecordNumber uint16
+	Values       []uint16
+}
+
+type ModbusReadFileRecordRequest interface {
+	ModbusOperation
+	SubRequests() []FileSubRequest
+}
+
+type ModbusWriteFileRecordRequest interface {
+	ModbusOperation
+	FileRecords() []FileRecord
+}
+
+func NewReadFileRecordRequest(subRequests []FileSubRequest) *ReadFileRecordRequest {
+	return &ReadFileRecordRequest{
+		subRequests: subRequests,
+	}
+}
+
+type ReadFileRecordRequest struct {
+	ModbusReadFileRecordRequest
+	subRequests []FileSubRequest
+}
+
+func (r ReadFileRecordRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddArray("SubRequests", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, s := range r.subRequests {
+			enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddUint16("FileNumber", s.FileNumber)
+				enc.AddUint16("RecordNumber", s.RecordNumber)
+				enc.AddUint16("RecordLength", s.RecordLength)
+				return nil
+			}))
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadFileRecordRequest) SubRequests() []FileSubRequest {
+	return r.subRequests
+}
+
+func NewWriteFileRecordRequest(records []FileRecord) *WriteFileRecordRequest {
+	return &WriteFileRecordRequest{
+		records: records,
+	}
+}
+
+type WriteFileRecordRequest struct {
+	ModbusWriteFileRecordRequest
+	records []FileRecord
+}
+
+func (r WriteFileRecordRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return marshalFileRecords(encoder, r.records)
+}
+
+func (r WriteFileRecordRequest) FileRecords() []FileRecord {
+	return r.records
+}
+
+func marshalFileRecords(encoder zapcore.ObjectEncoder, records []FileRecord) error {
+	encoder.AddArray("FileRecords", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, r := range records {
+			enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddUint16("FileNumber", r.FileNumber)
+				enc.AddUint16("RecordNumber", r.RecordNumber)
+				enc.AddArray("Values", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+					for _, v := range r.Values {
+						enc.AppendUint16(v)
+					}
+					return nil
+				}))
+				return nil
+			}))
+		}
+		return nil
+	}))
+	return nil
+}
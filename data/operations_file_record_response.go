@@ -0,0 +1,63 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+type ModbusReadFileRecordResponse interface {
+	ModbusOperation
+	// Records returns the values of each sub-request, in the order they were requested
+	Records() [][]uint16
+}
+
+// ModbusWriteFileRecordResponse is an echo of the request, so it has the same shape.
+type ModbusWriteFileRecordResponse interface {
+	ModbusWriteFileRecordRequest
+}
+
+func NewReadFileRecordResponse(records [][]uint16) *ReadFileRecordResponse {
+	return &ReadFileRecordResponse{
+		records: records,
+	}
+}
+
+type ReadFileRecordResponse struct {
+	ModbusReadFileRecordResponse
+	records [][]uint16
+}
+
+func (r ReadFileRecordResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddArray("Records", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, record := range r.records {
+			enc.AppendArray(zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+				for _, v := range record {
+					enc.AppendUint16(v)
+				}
+				return nil
+			}))
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadFileRecordResponse) Records() [][]uint16 {
+	return r.records
+}
+
+func NewWriteFileRecordResponse(records []FileRecord) *WriteFileRecordResponse {
+	return &WriteFileRecordResponse{
+		records: records,
+	}
+}
+
+type WriteFileRecordResponse struct {
+	ModbusWriteFileRecordResponse
+	records []FileRecord
+}
+
+func (r WriteFileRecordResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return marshalFileRecords(encoder, r.records)
+}
+
+func (r WriteFileRecordResponse) FileRecords() []FileRecord {
+	return r.records
+}
@@ -0,0 +1,58 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+type ModbusReadWriteArrayRequest interface {
+	ModbusOperation
+	CountableOperation
+	ReadOffset() uint16
+	WriteOffset() uint16
+	Values() []uint16
+}
+
+func NewReadWriteMultipleRegistersRequest(readOffset, readCount, writeOffset uint16, values []uint16) *ReadWriteMultipleRegistersRequest {
+	return &ReadWriteMultipleRegistersRequest{
+		readOffset:  readOffset,
+		readCount:   readCount,
+		writeOffset: writeOffset,
+		values:      values,
+	}
+}
+
+type ReadWriteMultipleRegistersRequest struct {
+	ModbusReadWriteArrayRequest
+	readOffset  uint16
+	readCount   uint16
+	writeOffset uint16
+	values      []uint16
+}
+
+func (r ReadWriteMultipleRegistersRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("ReadOffset", r.readOffset)
+	encoder.AddUint16("ReadCount", r.readCount)
+	encoder.AddUint16("WriteOffset", r.writeOffset)
+	encoder.AddArray("Values", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, v := range r.values {
+			enc.AppendUint16(v)
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadWriteMultipleRegistersRequest) ReadOffset() uint16 {
+	return r.readOffset
+}
+
+// Count returns the number of registers to read, the number of registers to write is len(Values()).
+func (r ReadWriteMultipleRegistersRequest) Count() int {
+	return int(r.readCount)
+}
+
+func (r ReadWriteMultipleRegistersRequest) WriteOffset() uint16 {
+	return r.writeOffset
+}
+
+func (r ReadWriteMultipleRegistersRequest) Values() []uint16 {
+	return r.values
+}
@@ -0,0 +1,32 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+func NewReadWriteMultipleRegistersResponse(values []uint16) *ReadWriteMultipleRegistersResponse {
+	return &ReadWriteMultipleRegistersResponse{
+		values: values,
+	}
+}
+
+type ReadWriteMultipleRegistersResponse struct {
+	ModbusReadResponse[[]uint16]
+	values []uint16
+}
+
+func (r ReadWriteMultipleRegistersResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddArray("Values", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, v := range r.values {
+			enc.AppendUint16(v)
+		}
+		return nil
+	}))
+	return nil
+}
+
+func (r ReadWriteMultipleRegistersResponse) Values() []uint16 {
+	return r.values
+}
+
+func (r ReadWriteMultipleRegistersResponse) Count() int {
+	return len(r.values)
+}
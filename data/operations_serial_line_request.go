@@ -0,0 +1,53 @@
+package data
+
+import "go.uber.org/zap/zapcore"
+
+// Read Exception Status, Get Comm Event Counter, Get Comm Event Log and Report Server ID requests carry no data, only the function code
+
+func NewReadExceptionStatusRequest() *ReadExceptionStatusRequest {
+	return &ReadExceptionStatusRequest{}
+}
+
+type ReadExceptionStatusRequest struct {
+	ModbusOperation
+}
+
+func (r ReadExceptionStatusRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return nil
+}
+
+func NewGetCommEventCounterRequest() *GetCommEventCounterRequest {
+	return &GetCommEventCounterRequest{}
+}
+
+type GetCommEventCounterRequest struct {
+	ModbusOperation
+}
+
+func (r GetCommEventCounterRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return nil
+}
+
+func NewGetCommEventLogRequest() *GetCommEventLogRequest {
+	return &GetCommEventLogRequest{}
+}
+
+type GetCommEventLogRequest struct {
+	ModbusOperation
+}
+
+func (r GetCommEventLogRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return nil
+}
+
+func NewReportServerIDRequest() *ReportServerIDRequest {
+	return &ReportServerIDRequest{}
+}
+
+type ReportServerIDRequest struct {
+	ModbusOperation
+}
+
+func (r ReportServerIDRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return nil
+}
@@ -0,0 +1,170 @@
+package data
+
+import (
+	"encoding/hex"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Comm event log entries, each event is a single byte and its meaning depends on bits 6 and 7
+const (
+	// ReceiveEvent is set on events logged when a request is received, the other bits describe the request
+	ReceiveEvent                   byte = 0x80
+	ReceiveEventCommunicationError byte = 0x02
+	ReceiveEventCharacterOverrun   byte = 0x10
+	ReceiveEventListenOnlyMode     byte = 0x20
+	ReceiveEventBroadcast          byte = 0x40
+
+	// SendEvent is set on events logged when a response is sent, the other bits describe the response
+	SendEvent                          byte = 0x40
+	SendEventReadException             byte = 0x01
+	SendEventServerAbortException      byte = 0x02
+	SendEventServerBusyException       byte = 0x04
+	SendEventServerProgramNAKException byte = 0x08
+	SendEventWriteTimeout              byte = 0x10
+	SendEventListenOnlyMode            byte = 0x20
+
+	// ListenOnlyModeEvent is logged when the server enters listen only mode
+	ListenOnlyModeEvent byte = 0x04
+	// CommunicationRestartEvent is logged when the server restarts communications
+	CommunicationRestartEvent byte = 0x00
+)
+
+type ModbusReadExceptionStatusResponse interface {
+	ModbusOperation
+	ExceptionStatus() byte
+}
+
+func NewReadExceptionStatusResponse(status byte) *ReadExceptionStatusResponse {
+	return &ReadExceptionStatusResponse{
+		status: status,
+	}
+}
+
+type ReadExceptionStatusResponse struct {
+	ModbusReadExceptionStatusResponse
+	status byte
+}
+
+func (r ReadExceptionStatusResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint8("ExceptionStatus", r.status)
+	return nil
+}
+
+func (r ReadExceptionStatusResponse) ExceptionStatus() byte {
+	return r.status
+}
+
+type ModbusGetCommEventCounterResponse interface {
+	ModbusOperation
+	Status() uint16
+	EventCount() uint16
+}
+
+func NewGetCommEventCounterResponse(status, eventCount uint16) *GetCommEventCounterResponse {
+	return &GetCommEventCounterResponse{
+		status:     status,
+		eventCount: eventCount,
+	}
+}
+
+type GetCommEventCounterResponse struct {
+	ModbusGetCommEventCounterResponse
+	status     uint16
+	eventCount uint16
+}
+
+func (r GetCommEventCounterResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("Status", r.status)
+	encoder.AddUint16("EventCount", r.eventCount)
+	return nil
+}
+
+func (r GetCommEventCounterResponse) Status() uint16 {
+	return r.status
+}
+
+func (r GetCommEventCounterResponse) EventCount() uint16 {
+	return r.eventCount
+}
+
+type ModbusGetCommEventLogResponse interface {
+	ModbusGetCommEventCounterResponse
+	MessageCount() uint16
+	// Events returns up to 64 event bytes, the most recent event first
+	Events() []byte
+}
+
+func NewGetCommEventLogResponse(status, eventCount, messageCount uint16, events []byte) *GetCommEventLogResponse {
+	return &GetCommEventLogResponse{
+		status:       status,
+		eventCount:   eventCount,
+		messageCount: messageCount,
+		events:       events,
+	}
+}
+
+type GetCommEventLogResponse struct {
+	ModbusGetCommEventLogResponse
+	status       uint16
+	eventCount   uint16
+	messageCount uint16
+	events       []byte
+}
+
+func (r GetCommEventLogResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("Status", r.status)
+	encoder.AddUint16("EventCount", r.eventCount)
+	encoder.AddUint16("MessageCount", r.messageCount)
+	encoder.AddString("Events", hex.EncodeToString(r.events))
+	return nil
+}
+
+func (r GetCommEventLogResponse) Status() uint16 {
+	return r.status
+}
+
+func (r GetCommEventLogResponse) EventCount() uint16 {
+	return r.eventCount
+}
+
+func (r GetCommEventLogResponse) MessageCount() uint16 {
+	return r.messageCount
+}
+
+func (r GetCommEventLogResponse) Events() []byte {
+	return r.events
+}
+
+type ModbusReportServerIDResponse interface {
+	ModbusOperation
+	ServerID() []byte
+	RunIndicator() bool
+}
+
+func NewReportServerIDResponse(serverID []byte, runIndicator bool) *ReportServerIDResponse {
+	return &ReportServerIDResponse{
+		serverID:     serverID,
+		runIndicator: runIndicator,
+	}
+}
+
+type ReportServerIDResponse struct {
+	ModbusReportServerIDResponse
+	serverID     []byte
+	runIndicator bool
+}
+
+func (r ReportServerIDResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("ServerID", hex.EncodeToString(r.serverID))
+	encoder.AddBool("RunIndicator", r.runIndicator)
+	return nil
+}
+
+func (r ReportServerIDResponse) ServerID() []byte {
+	return r.serverID
+}
+
+func (r ReportServerIDResponse) RunIndicator() bool {
+	return r.runIndicator
+}
@@ -1,8 +1,10 @@
 package data
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/rinzlerlabs/gomodbus/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,14 +12,16 @@ func TestReadCoilsResponse_Bytes(t *testing.T) {
 	values := []bool{true, false, false, false, false, false, false, true, true, false, false, false, false, false, false, true}
 	response := ReadCoilsResponse{values: values}
 	expected := []byte{0x02, 0x81, 0x81}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestReadCoilsRequest_Bytes(t *testing.T) {
 	request := ReadCoilsRequest{offset: 0, count: 16}
 	expected := []byte{0x00, 0x00, 0x00, 0x10}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
@@ -25,14 +29,16 @@ func TestReadDiscreteInputsResponse_Bytes(t *testing.T) {
 	values := []bool{true, false, false, false, false, false, false, true, true, false, false, false, false, false, false, true}
 	response := ReadDiscreteInputsResponse{values: values}
 	expected := []byte{0x02, 0x81, 0x81}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestReadDiscreteInputsRequest_Bytes(t *testing.T) {
 	request := ReadDiscreteInputsRequest{offset: 0, count: 16}
 	expected := []byte{0x00, 0x00, 0x00, 0x10}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
@@ -40,14 +46,16 @@ func TestReadHoldingRegistersResponse_Bytes(t *testing.T) {
 	values := []uint16{0x0001, 0x0002, 0x0003, 0x0004}
 	response := ReadHoldingRegistersResponse{values: values}
 	expected := []byte{0x08, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestReadHoldingRegistersRequest_Bytes(t *testing.T) {
 	request := ReadHoldingRegistersRequest{offset: 0, count: 4}
 	expected := []byte{0x00, 0x00, 0x00, 0x04}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
@@ -55,49 +63,56 @@ func TestReadInputRegistersResponse_Bytes(t *testing.T) {
 	values := []uint16{0x0001, 0x0002, 0x0003, 0x0004}
 	response := ReadInputRegistersResponse{values: values}
 	expected := []byte{0x08, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestReadInputRegistersRequest_Bytes(t *testing.T) {
 	request := ReadInputRegistersRequest{offset: 0, count: 4}
 	expected := []byte{0x00, 0x00, 0x00, 0x04}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteSingleCoilResponse_Bytes(t *testing.T) {
 	response := WriteSingleCoilResponse{offset: 0, value: true}
 	expected := []byte{0x00, 0x00, 0xFF, 0x00}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteSingleCoilRequest_Bytes(t *testing.T) {
 	request := WriteSingleCoilRequest{offset: 0, value: true}
 	expected := []byte{0x00, 0x00, 0xFF, 0x00}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteSingleRegisterResponse_Bytes(t *testing.T) {
 	response := WriteSingleRegisterResponse{offset: 0, value: 0x0001}
 	expected := []byte{0x00, 0x00, 0x00, 0x01}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteSingleRegisterRequest_Bytes(t *testing.T) {
 	request := WriteSingleRegisterRequest{offset: 0, value: 0x0001}
 	expected := []byte{0x00, 0x00, 0x00, 0x01}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteMultipleCoilsResponse_Bytes(t *testing.T) {
 	response := WriteMultipleCoilsResponse{offset: 0, count: 16}
 	expected := []byte{0x00, 0x00, 0x00, 0x10}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
@@ -105,14 +120,16 @@ func TestWriteMultipleCoilsRequest_Bytes(t *testing.T) {
 	values := []bool{true, false, false, false, false, false, false, true, true, false, false, false, false, false, false, true}
 	request := WriteMultipleCoilsRequest{offset: 0, values: values}
 	expected := []byte{0x00, 0x00, 0x00, 0x10, 0x02, 0x81, 0x81}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
 func TestWriteMultipleRegistersResponse_Bytes(t *testing.T) {
 	response := WriteMultipleRegistersResponse{offset: 0, count: 4}
 	expected := []byte{0x00, 0x00, 0x00, 0x04}
-	result := ModbusOperationToBytes(response)
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
@@ -120,6 +137,253 @@ func TestWriteMultipleRegistersRequest_Bytes(t *testing.T) {
 	values := []uint16{0x0001, 0x0002, 0x0003, 0x0004}
 	request := WriteMultipleRegistersRequest{offset: 0, values: values}
 	expected := []byte{0x00, 0x00, 0x00, 0x04, 0x08, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04}
-	result := ModbusOperationToBytes(request)
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
+
+func TestReadWriteMultipleRegistersResponse_Bytes(t *testing.T) {
+	values := []uint16{0x00FE, 0x0ACD, 0x0001, 0x0003, 0x000D, 0x00FF}
+	response := ReadWriteMultipleRegistersResponse{values: values}
+	expected := []byte{0x0C, 0x00, 0xFE, 0x0A, 0xCD, 0x00, 0x01, 0x00, 0x03, 0x00, 0x0D, 0x00, 0xFF}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadWriteMultipleRegistersRequest_Bytes(t *testing.T) {
+	values := []uint16{0x00FF, 0x00FF, 0x00FF}
+	request := ReadWriteMultipleRegistersRequest{readOffset: 0x0003, readCount: 6, writeOffset: 0x000E, values: values}
+	expected := []byte{0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestMaskWriteRegisterResponse_Bytes(t *testing.T) {
+	response := MaskWriteRegisterResponse{offset: 4, andMask: 0x00F2, orMask: 0x0025}
+	expected := []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestMaskWriteRegisterRequest_Bytes(t *testing.T) {
+	request := MaskWriteRegisterRequest{offset: 4, andMask: 0x00F2, orMask: 0x0025}
+	expected := []byte{0x00, 0x04, 0x00, 0xF2, 0x00, 0x25}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadDeviceIdentificationRequest_Bytes(t *testing.T) {
+	request := ReadDeviceIdentificationRequest{code: BasicDeviceIdentification, objectID: VendorName}
+	expected := []byte{0x0E, 0x01, 0x00}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadDeviceIdentificationResponse_Bytes(t *testing.T) {
+	objects := []DeviceIdentificationObject{{ID: VendorName, Value: []byte("ABC")}, {ID: ProductCode, Value: []byte("P1")}}
+	response := ReadDeviceIdentificationResponse{code: BasicDeviceIdentification, conformityLevel: 0x81, moreFollows: true, nextObjectID: MajorMinorRevision, objects: objects}
+	expected := []byte{0x0E, 0x01, 0x81, 0xFF, 0x02, 0x02, 0x00, 0x03, 0x41, 0x42, 0x43, 0x01, 0x02, 0x50, 0x31}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestDiagnosticsRequest_Bytes(t *testing.T) {
+	request := DiagnosticsRequest{subFunction: ReturnQueryData, data: []byte{0xA5, 0x37}}
+	expected := []byte{0x00, 0x00, 0xA5, 0x37}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestDiagnosticsResponse_Bytes(t *testing.T) {
+	response := DiagnosticsResponse{subFunction: ReturnBusMessageCount, data: []byte{0x00, 0x05}}
+	expected := []byte{0x00, 0x0B, 0x00, 0x05}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadExceptionStatusRequest_Bytes(t *testing.T) {
+	result, err := ModbusOperationToBytes(NewReadExceptionStatusRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{}, result)
+}
+
+func TestGetCommEventCounterResponse_Bytes(t *testing.T) {
+	response := GetCommEventCounterResponse{status: 0xFFFF, eventCount: 0x0108}
+	expected := []byte{0xFF, 0xFF, 0x01, 0x08}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestGetCommEventLogResponse_Bytes(t *testing.T) {
+	response := GetCommEventLogResponse{status: 0x0000, eventCount: 0x0108, messageCount: 0x0121, events: []byte{0x20, 0x00}}
+	expected := []byte{0x08, 0x00, 0x00, 0x01, 0x08, 0x01, 0x21, 0x20, 0x00}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReportServerIDResponse_Bytes(t *testing.T) {
+	response := ReportServerIDResponse{serverID: []byte{0x50, 0x31}, runIndicator: true}
+	expected := []byte{0x03, 0x50, 0x31, 0xFF}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadFIFOQueueRequest_Bytes(t *testing.T) {
+	request := ReadFIFOQueueRequest{pointer: 0x04DE}
+	expected := []byte{0x04, 0xDE}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadFIFOQueueResponse_Bytes(t *testing.T) {
+	response := ReadFIFOQueueResponse{queue: []uint16{0x01B8, 0x1284}}
+	expected := []byte{0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadFileRecordRequest_Bytes(t *testing.T) {
+	request := ReadFileRecordRequest{subRequests: []FileSubRequest{{FileNumber: 0x0004, RecordNumber: 0x0001, RecordLength: 2}, {FileNumber: 0x0003, RecordNumber: 0x0009, RecordLength: 2}}}
+	expected := []byte{0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestReadFileRecordResponse_Bytes(t *testing.T) {
+	response := ReadFileRecordResponse{records: [][]uint16{{0x0DFE, 0x0020}, {0x33CD, 0x0040}}}
+	expected := []byte{0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40}
+	result, err := ModbusOperationToBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestWriteFileRecordRequest_Bytes(t *testing.T) {
+	request := WriteFileRecordRequest{records: []FileRecord{{FileNumber: 0x0004, RecordNumber: 0x0007, Values: []uint16{0x06AF, 0x04BE, 0x100D}}}}
+	expected := []byte{0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D}
+	result, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestRegisterCustomFunction(t *testing.T) {
+	tests := []struct {
+		name          string
+		functionCode  FunctionCode
+		expectedError error
+	}{
+		{
+			name:         "Valid_UserDefined",
+			functionCode: 0x64,
+		},
+		{
+			name:          "StandardFunctionCode",
+			functionCode:  ReadHoldingRegisters,
+			expectedError: common.ErrInvalidFunctionCode,
+		},
+		{
+			name:          "ExceptionFunctionCode",
+			functionCode:  0xE4,
+			expectedError: common.ErrInvalidFunctionCode,
+		},
+		{
+			name:          "ZeroFunctionCode",
+			functionCode:  0x00,
+			expectedError: common.ErrInvalidFunctionCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterCustomFunction(CustomFunction{FunctionCode: tt.functionCode})
+			assert.Equal(t, tt.expectedError, err)
+			if err == nil {
+				assert.Equal(t, common.ErrFunctionCodeRegistered, RegisterCustomFunction(CustomFunction{FunctionCode: tt.functionCode}))
+				UnregisterCustomFunction(tt.functionCode)
+				_, ok := LookupCustomFunction(tt.functionCode)
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestCustomFunctionParsing(t *testing.T) {
+	assert.NoError(t, RegisterCustomFunction(CustomFunction{FunctionCode: 0x65}))
+	defer UnregisterCustomFunction(0x65)
+
+	request, err := ParseModbusRequestOperation(0x65, []byte{0x00, 0x01})
+	assert.NoError(t, err)
+	assert.Equal(t, NewCustomOperation(0x65, []byte{0x00, 0x01}), request)
+	requestBytes, err := ModbusOperationToBytes(request)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01}, requestBytes)
+
+	response, err := ParseModbusResponseOperation(0x65, []byte{0x02, 0xAB, 0xCD}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, NewCustomOperation(0x65, []byte{0x02, 0xAB, 0xCD}), response)
+
+	exception, err := ParseModbusResponseOperation(0xE5, []byte{0x02}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, NewModbusOperationException(0x65, IllegalDataAddress), exception)
+
+	_, err = ParseModbusRequestOperation(0x66, []byte{0x00, 0x01})
+	assert.Equal(t, common.ErrInvalidFunctionCode, err)
+}
+
+func TestByteCountFrameLength(t *testing.T) {
+	length, ok := ByteCountFrameLength([]byte{})
+	assert.False(t, ok)
+	assert.Equal(t, 1, length)
+	length, ok = ByteCountFrameLength([]byte{0x04})
+	assert.True(t, ok)
+	assert.Equal(t, 5, length)
+}
+
+func TestModbusException(t *testing.T) {
+	var err error = NewModbusOperationException(ReadHoldingRegisters, IllegalDataAddress).Exception(0x04)
+	var exception *ModbusException
+	assert.True(t, errors.As(err, &exception))
+	assert.Equal(t, ReadHoldingRegisters, exception.FunctionCode)
+	assert.Equal(t, IllegalDataAddress, exception.ExceptionCode)
+	assert.Equal(t, uint16(0x04), exception.Address)
+	assert.True(t, errors.Is(err, common.ErrIllegalDataAddress))
+	assert.False(t, errors.Is(err, common.ErrServerDeviceBusy))
+	assert.Equal(t, "illegal data address: device 4, function 0x03", err.Error())
+}
+
+func TestParseReadResponseCounts(t *testing.T) {
+	// The largest read the spec allows, 2000 coils in 250 bytes
+	coils := append([]byte{0xFA}, make([]byte, 250)...)
+	coils[250] = 0x80
+	response, err := ParseModbusResponseOperation(ReadCoils, coils, 2000)
+	assert.NoError(t, err)
+	values := response.(*ReadCoilsResponse).Values()
+	assert.Len(t, values, 2000)
+	assert.True(t, values[1999])
+
+	// Responses with fewer values than were asked for
+	_, err = ParseModbusResponseOperation(ReadDiscreteInputs, []byte{0x01, 0xFF}, 9)
+	assert.ErrorIs(t, err, common.ErrInvalidPacket)
+	_, err = ParseModbusResponseOperation(ReadHoldingRegisters, []byte{0x02, 0x00, 0x01}, 2)
+	assert.ErrorIs(t, err, common.ErrInvalidPacket)
+	_, err = ParseModbusResponseOperation(ReadInputRegisters, []byte{0x02, 0x00, 0x01}, 2)
+	assert.ErrorIs(t, err, common.ErrInvalidPacket)
+}
+
+func TestModbusOperationToBytes_UnknownOperation(t *testing.T) {
+	_, err := ModbusOperationToBytes(nil)
+	assert.ErrorIs(t, err, common.ErrUnknownOperation)
+}
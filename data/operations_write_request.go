@@ -15,6 +15,13 @@ type ModbusWriteArrayRequest[T []bool | []uint16] interface {
 	Values() T
 }
 
+type ModbusMaskWriteRequest interface {
+	ModbusOperation
+	Offset() uint16
+	AndMask() uint16
+	OrMask() uint16
+}
+
 func NewWriteSingleCoilRequest(offset uint16, value bool) *WriteSingleCoilRequest {
 	return &WriteSingleCoilRequest{
 		offset: offset,
@@ -140,3 +147,37 @@ func (r WriteMultipleRegistersRequest) Values() []uint16 {
 func (r WriteMultipleRegistersRequest) Count() int {
 	return len(r.values)
 }
+
+func NewMaskWriteRegisterRequest(offset, andMask, orMask uint16) *MaskWriteRegisterRequest {
+	return &MaskWriteRegisterRequest{
+		offset:  offset,
+		andMask: andMask,
+		orMask:  orMask,
+	}
+}
+
+type MaskWriteRegisterRequest struct {
+	ModbusMaskWriteRequest
+	offset  uint16
+	andMask uint16
+	orMask  uint16
+}
+
+func (r MaskWriteRegisterRequest) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("AndMask", r.andMask)
+	encoder.AddUint16("OrMask", r.orMask)
+	encoder.AddUint16("Offset", r.offset)
+	return nil
+}
+
+func (r MaskWriteRegisterRequest) Offset() uint16 {
+	return r.offset
+}
+
+func (r MaskWriteRegisterRequest) AndMask() uint16 {
+	return r.andMask
+}
+
+func (r MaskWriteRegisterRequest) OrMask() uint16 {
+	return r.orMask
+}
@@ -15,6 +15,13 @@ type ModbusWriteArrayResponse[T []bool | []uint16] interface {
 	Value() T
 }
 
+type ModbusMaskWriteResponse interface {
+	ModbusOperation
+	Offset() uint16
+	AndMask() uint16
+	OrMask() uint16
+}
+
 func NewWriteSingleCoilResponse(offset uint16, value bool) *WriteSingleCoilResponse {
 	return &WriteSingleCoilResponse{
 		offset: offset,
@@ -122,3 +129,37 @@ func (r WriteMultipleRegistersResponse) Offset() uint16 {
 func (r WriteMultipleRegistersResponse) Count() int {
 	return int(r.count)
 }
+
+func NewMaskWriteRegisterResponse(offset, andMask, orMask uint16) *MaskWriteRegisterResponse {
+	return &MaskWriteRegisterResponse{
+		offset:  offset,
+		andMask: andMask,
+		orMask:  orMask,
+	}
+}
+
+type MaskWriteRegisterResponse struct {
+	ModbusMaskWriteResponse
+	offset  uint16
+	andMask uint16
+	orMask  uint16
+}
+
+func (r MaskWriteRegisterResponse) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddUint16("AndMask", r.andMask)
+	encoder.AddUint16("OrMask", r.orMask)
+	encoder.AddUint16("Offset", r.offset)
+	return nil
+}
+
+func (r MaskWriteRegisterResponse) Offset() uint16 {
+	return r.offset
+}
+
+func (r MaskWriteRegisterResponse) AndMask() uint16 {
+	return r.andMask
+}
+
+func (r MaskWriteRegisterResponse) OrMask() uint16 {
+	return r.orMask
+}
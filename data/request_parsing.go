@@ -18,12 +18,38 @@ func ParseModbusRequestOperation(functionCode FunctionCode, bytes []byte) (Modbu
 		op, err = newWriteSingleCoilRequest(bytes)
 	case WriteSingleRegister:
 		op, err = newWriteSingleRegisterRequest(bytes)
+	case ReadExceptionStatus:
+		op, err = newReadExceptionStatusRequest(bytes)
+	case Diagnostics:
+		op, err = newDiagnosticsRequest(bytes)
+	case GetCommEventCounter:
+		op, err = newGetCommEventCounterRequest(bytes)
+	case GetCommEventLog:
+		op, err = newGetCommEventLogRequest(bytes)
 	case WriteMultipleCoils:
 		op, err = newWriteMultipleCoilsRequest(bytes)
 	case WriteMultipleRegisters:
 		op, err = newWriteMultipleRegistersRequest(bytes)
+	case ReportServerID:
+		op, err = newReportServerIDRequest(bytes)
+	case ReadFileRecord:
+		op, err = newReadFileRecordRequest(bytes)
+	case WriteFileRecord:
+		op, err = newWriteFileRecordRequest(bytes)
+	case MaskWriteRegister:
+		op, err = newMaskWriteRegisterRequest(bytes)
+	case ReadWriteMultipleRegisters:
+		op, err = newReadWriteMultipleRegistersRequest(bytes)
+	case ReadFIFOQueue:
+		op, err = newReadFIFOQueueRequest(bytes)
+	case EncapsulatedInterfaceTransport:
+		op, err = newReadDeviceIdentificationRequest(bytes)
 	default:
-		return nil, common.ErrInvalidFunctionCode
+		custom, ok := LookupCustomFunction(functionCode)
+		if !ok {
+			return nil, common.ErrInvalidFunctionCode
+		}
+		op, err = custom.parseRequest(bytes)
 	}
 	return op, err
 }
@@ -88,6 +114,104 @@ func newWriteSingleRegisterRequest(bytes []byte) (*WriteSingleRegisterRequest, e
 	}, nil
 }
 
+func newReadExceptionStatusRequest(bytes []byte) (*ReadExceptionStatusRequest, error) {
+	if len(bytes) != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReadExceptionStatusRequest{}, nil
+}
+
+func newGetCommEventCounterRequest(bytes []byte) (*GetCommEventCounterRequest, error) {
+	if len(bytes) != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &GetCommEventCounterRequest{}, nil
+}
+
+func newGetCommEventLogRequest(bytes []byte) (*GetCommEventLogRequest, error) {
+	if len(bytes) != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &GetCommEventLogRequest{}, nil
+}
+
+func newReportServerIDRequest(bytes []byte) (*ReportServerIDRequest, error) {
+	if len(bytes) != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReportServerIDRequest{}, nil
+}
+
+func newReadFileRecordRequest(bytes []byte) (*ReadFileRecordRequest, error) {
+	// Each sub-request is 7 bytes, and the spec allows between 1 and 35 of them
+	if len(bytes) < 8 || int(bytes[0]) != len(bytes)-1 || bytes[0]%7 != 0 || bytes[0] > 0xF5 {
+		return nil, common.ErrInvalidPacket
+	}
+	subRequests := make([]FileSubRequest, 0, bytes[0]/7)
+	for i := 1; i < len(bytes); i += 7 {
+		if bytes[i] != FileRecordReferenceType {
+			return nil, common.ErrInvalidPacket
+		}
+		subRequests = append(subRequests, FileSubRequest{
+			FileNumber:   uint16(bytes[i+1])<<8 | uint16(bytes[i+2]),
+			RecordNumber: uint16(bytes[i+3])<<8 | uint16(bytes[i+4]),
+			RecordLength: uint16(bytes[i+5])<<8 | uint16(bytes[i+6]),
+		})
+	}
+	return &ReadFileRecordRequest{
+		subRequests: subRequests,
+	}, nil
+}
+
+func newWriteFileRecordRequest(bytes []byte) (*WriteFileRecordRequest, error) {
+	records, err := parseFileRecords(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &WriteFileRecordRequest{
+		records: records,
+	}, nil
+}
+
+// parseFileRecords parses the body of a Write File Record request or response
+func parseFileRecords(bytes []byte) ([]FileRecord, error) {
+	if len(bytes) < 8 || int(bytes[0]) != len(bytes)-1 || bytes[0] > 0xF5 {
+		return nil, common.ErrInvalidPacket
+	}
+	records := make([]FileRecord, 0)
+	for i := 1; i < len(bytes); {
+		if i+7 > len(bytes) || bytes[i] != FileRecordReferenceType {
+			return nil, common.ErrInvalidPacket
+		}
+		length := int(bytes[i+5])<<8 | int(bytes[i+6])
+		if i+7+length*2 > len(bytes) {
+			return nil, common.ErrInvalidPacket
+		}
+		values := make([]uint16, length)
+		for j := range values {
+			values[j] = uint16(bytes[i+7+j*2])<<8 | uint16(bytes[i+8+j*2])
+		}
+		records = append(records, FileRecord{
+			FileNumber:   uint16(bytes[i+1])<<8 | uint16(bytes[i+2]),
+			RecordNumber: uint16(bytes[i+3])<<8 | uint16(bytes[i+4]),
+			Values:       values,
+		})
+		i += 7 + length*2
+	}
+	return records, nil
+}
+
+func newDiagnosticsRequest(bytes []byte) (*DiagnosticsRequest, error) {
+	// The sub-function is followed by zero or more 2 byte data fields
+	if len(bytes) < 2 || len(bytes)%2 != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &DiagnosticsRequest{
+		subFunction: DiagnosticSubFunction(uint16(bytes[0])<<8 | uint16(bytes[1])),
+		data:        bytes[2:],
+	}, nil
+}
+
 func newWriteMultipleCoilsRequest(bytes []byte) (*WriteMultipleCoilsRequest, error) {
 	if len(bytes) < 5 {
 		return nil, common.ErrInvalidPacket
@@ -133,3 +257,64 @@ func newWriteMultipleRegistersRequest(bytes []byte) (*WriteMultipleRegistersRequ
 		values: values,
 	}, nil
 }
+
+func newMaskWriteRegisterRequest(bytes []byte) (*MaskWriteRegisterRequest, error) {
+	if len(bytes) != 6 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &MaskWriteRegisterRequest{
+		offset:  uint16(bytes[0])<<8 | uint16(bytes[1]),
+		andMask: uint16(bytes[2])<<8 | uint16(bytes[3]),
+		orMask:  uint16(bytes[4])<<8 | uint16(bytes[5]),
+	}, nil
+}
+
+func newReadWriteMultipleRegistersRequest(bytes []byte) (*ReadWriteMultipleRegistersRequest, error) {
+	if len(bytes) < 9 {
+		return nil, common.ErrInvalidPacket
+	}
+	readOffset := uint16(bytes[0])<<8 | uint16(bytes[1])
+	readCount := uint16(bytes[2])<<8 | uint16(bytes[3])
+	writeOffset := uint16(bytes[4])<<8 | uint16(bytes[5])
+	writeCount := uint16(bytes[6])<<8 | uint16(bytes[7])
+	bytecount := uint16(bytes[8])
+	if uint16(len(bytes)) != 2+2+2+2+1+bytecount {
+		return nil, common.ErrInvalidPacket
+	}
+	if bytecount != writeCount*2 {
+		return nil, common.ErrInvalidPacket
+	}
+	values := make([]uint16, writeCount)
+	for i := uint16(0); i < writeCount; i++ {
+		values[i] = uint16(bytes[9+i*2])<<8 | uint16(bytes[10+i*2])
+	}
+	return &ReadWriteMultipleRegistersRequest{
+		readOffset:  readOffset,
+		readCount:   readCount,
+		writeOffset: writeOffset,
+		values:      values,
+	}, nil
+}
+
+func newReadFIFOQueueRequest(bytes []byte) (*ReadFIFOQueueRequest, error) {
+	if len(bytes) != 2 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReadFIFOQueueRequest{
+		pointer: uint16(bytes[0])<<8 | uint16(bytes[1]),
+	}, nil
+}
+
+func newReadDeviceIdentificationRequest(bytes []byte) (*ReadDeviceIdentificationRequest, error) {
+	if len(bytes) != 3 {
+		return nil, common.ErrInvalidPacket
+	}
+	// Read Device Identification is the only MEI type we support
+	if MEIType(bytes[0]) != ReadDeviceIdentificationMEI {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReadDeviceIdentificationRequest{
+		code:     DeviceIDCode(bytes[1]),
+		objectID: DeviceObjectID(bytes[2]),
+	}, nil
+}
@@ -20,10 +20,32 @@ func ParseModbusResponseOperation(functionCode FunctionCode, bytes []byte, value
 		op, err = newWriteSingleCoilResponse(bytes)
 	case WriteSingleRegister:
 		op, err = newWriteSingleRegisterResponse(bytes)
+	case ReadExceptionStatus:
+		op, err = newReadExceptionStatusResponse(bytes)
+	case Diagnostics:
+		op, err = newDiagnosticsResponse(bytes)
+	case GetCommEventCounter:
+		op, err = newGetCommEventCounterResponse(bytes)
+	case GetCommEventLog:
+		op, err = newGetCommEventLogResponse(bytes)
 	case WriteMultipleCoils:
 		op, err = newWriteMultipleCoilsResponse(bytes)
 	case WriteMultipleRegisters:
 		op, err = newWriteMultipleRegistersResponse(bytes)
+	case ReportServerID:
+		op, err = newReportServerIDResponse(bytes)
+	case ReadFileRecord:
+		op, err = newReadFileRecordResponse(bytes)
+	case WriteFileRecord:
+		op, err = newWriteFileRecordResponse(bytes)
+	case MaskWriteRegister:
+		op, err = newMaskWriteRegisterResponse(bytes)
+	case ReadWriteMultipleRegisters:
+		op, err = newReadWriteMultipleRegistersResponse(bytes, valueCount)
+	case ReadFIFOQueue:
+		op, err = newReadFIFOQueueResponse(bytes)
+	case EncapsulatedInterfaceTransport:
+		op, err = newReadDeviceIdentificationResponse(bytes)
 	case ReadCoilsError:
 		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
 	case ReadDiscreteInputsError:
@@ -36,12 +58,45 @@ func ParseModbusResponseOperation(functionCode FunctionCode, bytes []byte, value
 		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
 	case WriteSingleRegisterError:
 		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case ReadExceptionStatusError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case DiagnosticsError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case GetCommEventCounterError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case GetCommEventLogError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
 	case WriteMultipleCoilsError:
 		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
 	case WriteMultipleRegistersError:
 		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case ReportServerIDError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case ReadFileRecordError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case WriteFileRecordError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case MaskWriteRegisterError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case ReadWriteMultipleRegistersError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case ReadFIFOQueueError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+	case EncapsulatedInterfaceTransportError:
+		op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
 	default:
-		return nil, common.ErrInvalidFunctionCode
+		if functionCode.IsException() {
+			if _, ok := LookupCustomFunction(functionCode - 0x80); !ok {
+				return nil, common.ErrInvalidFunctionCode
+			}
+			op, err = NewModbusOperationExceptionFromResponse(functionCode, bytes)
+			break
+		}
+		custom, ok := LookupCustomFunction(functionCode)
+		if !ok {
+			return nil, common.ErrInvalidFunctionCode
+		}
+		op, err = custom.parseResponse(bytes)
 	}
 	return op, err
 }
@@ -54,7 +109,10 @@ func newReadCoilsResponse(b []byte, requestCount int) (*ReadCoilsResponse, error
 	if len(b) != 1+int(byteCount) {
 		return nil, common.ErrInvalidPacket
 	}
-	values := make([]bool, 8*byteCount)
+	if requestCount > 8*int(byteCount) {
+		return nil, common.ErrInvalidPacket
+	}
+	values := make([]bool, 8*int(byteCount))
 	for i := 0; i < 8*int(byteCount); i++ {
 		values[i] = b[1+i/8]&(1<<uint(i%8)) != 0
 	}
@@ -71,7 +129,10 @@ func newReadDiscreteInputsResponse(b []byte, requestCount int) (*ReadDiscreteInp
 	if len(b) != 1+int(byteCount) {
 		return nil, common.ErrInvalidPacket
 	}
-	values := make([]bool, 8*byteCount)
+	if requestCount > 8*int(byteCount) {
+		return nil, common.ErrInvalidPacket
+	}
+	values := make([]bool, 8*int(byteCount))
 	for i := 0; i < 8*int(byteCount); i++ {
 		values[i] = b[1+i/8]&(1<<uint(i%8)) != 0
 	}
@@ -89,6 +150,9 @@ func newReadHoldingRegistersResponse(b []byte, requestCount int) (*ReadHoldingRe
 		return nil, common.ErrInvalidPacket
 	}
 	values := make([]uint16, byteCount/2)
+	if requestCount > len(values) {
+		return nil, common.ErrInvalidPacket
+	}
 	for i := 0; i < len(values); i++ {
 		values[i] = uint16(b[1+2*i])<<8 | uint16(b[2+2*i])
 	}
@@ -106,6 +170,9 @@ func newReadInputRegistersResponse(b []byte, requestCount int) (*ReadInputRegist
 		return nil, common.ErrInvalidPacket
 	}
 	values := make([]uint16, byteCount/2)
+	if requestCount > len(values) {
+		return nil, common.ErrInvalidPacket
+	}
 	for i := 0; i < len(values); i++ {
 		values[i] = uint16(b[1+2*i])<<8 | uint16(b[2+2*i])
 	}
@@ -134,6 +201,95 @@ func newWriteSingleRegisterResponse(b []byte) (*WriteSingleRegisterResponse, err
 	}, nil
 }
 
+func newReadExceptionStatusResponse(b []byte) (*ReadExceptionStatusResponse, error) {
+	if len(b) != 1 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReadExceptionStatusResponse{
+		status: b[0],
+	}, nil
+}
+
+func newGetCommEventCounterResponse(b []byte) (*GetCommEventCounterResponse, error) {
+	if len(b) != 4 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &GetCommEventCounterResponse{
+		status:     uint16(b[0])<<8 | uint16(b[1]),
+		eventCount: uint16(b[2])<<8 | uint16(b[3]),
+	}, nil
+}
+
+func newGetCommEventLogResponse(b []byte) (*GetCommEventLogResponse, error) {
+	// The byte count covers the status, event count and message count words followed by 0 to 64 events
+	if len(b) < 7 || int(b[0]) != len(b)-1 || len(b)-7 > 64 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &GetCommEventLogResponse{
+		status:       uint16(b[1])<<8 | uint16(b[2]),
+		eventCount:   uint16(b[3])<<8 | uint16(b[4]),
+		messageCount: uint16(b[5])<<8 | uint16(b[6]),
+		events:       b[7:],
+	}, nil
+}
+
+func newReportServerIDResponse(b []byte) (*ReportServerIDResponse, error) {
+	// The server ID is device specific, so we assume the run indicator is the last byte and there is no additional data
+	if len(b) < 2 || int(b[0]) != len(b)-1 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReportServerIDResponse{
+		serverID:     b[1 : len(b)-1],
+		runIndicator: b[len(b)-1] == 0xFF,
+	}, nil
+}
+
+func newReadFileRecordResponse(b []byte) (*ReadFileRecordResponse, error) {
+	if len(b) < 1 || int(b[0]) != len(b)-1 {
+		return nil, common.ErrInvalidPacket
+	}
+	records := make([][]uint16, 0)
+	for i := 1; i < len(b); {
+		// Each sub-response is a length byte, which covers the reference type and the values, then the reference type and the values
+		if i+2 > len(b) || b[i+1] != FileRecordReferenceType {
+			return nil, common.ErrInvalidPacket
+		}
+		length := int(b[i])
+		if length%2 != 1 || i+1+length > len(b) {
+			return nil, common.ErrInvalidPacket
+		}
+		values := make([]uint16, (length-1)/2)
+		for j := range values {
+			values[j] = uint16(b[i+2+j*2])<<8 | uint16(b[i+3+j*2])
+		}
+		records = append(records, values)
+		i += 1 + length
+	}
+	return &ReadFileRecordResponse{
+		records: records,
+	}, nil
+}
+
+func newWriteFileRecordResponse(b []byte) (*WriteFileRecordResponse, error) {
+	records, err := parseFileRecords(b)
+	if err != nil {
+		return nil, err
+	}
+	return &WriteFileRecordResponse{
+		records: records,
+	}, nil
+}
+
+func newDiagnosticsResponse(b []byte) (*DiagnosticsResponse, error) {
+	if len(b) < 2 || len(b)%2 != 0 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &DiagnosticsResponse{
+		subFunction: DiagnosticSubFunction(uint16(b[0])<<8 | uint16(b[1])),
+		data:        b[2:],
+	}, nil
+}
+
 func newWriteMultipleCoilsResponse(b []byte) (*WriteMultipleCoilsResponse, error) {
 	if len(b) != 4 {
 		return nil, common.ErrInvalidPacket
@@ -154,6 +310,91 @@ func newWriteMultipleRegistersResponse(b []byte) (*WriteMultipleRegistersRespons
 	}, nil
 }
 
+func newMaskWriteRegisterResponse(b []byte) (*MaskWriteRegisterResponse, error) {
+	if len(b) != 6 {
+		return nil, common.ErrInvalidPacket
+	}
+	return &MaskWriteRegisterResponse{
+		offset:  uint16(b[0])<<8 | uint16(b[1]),
+		andMask: uint16(b[2])<<8 | uint16(b[3]),
+		orMask:  uint16(b[4])<<8 | uint16(b[5]),
+	}, nil
+}
+
+func newReadWriteMultipleRegistersResponse(b []byte, requestCount int) (*ReadWriteMultipleRegistersResponse, error) {
+	if len(b) < 1 {
+		return nil, common.ErrInvalidPacket
+	}
+	byteCount := b[0]
+	if len(b) != 1+int(byteCount) {
+		return nil, common.ErrInvalidPacket
+	}
+	values := make([]uint16, byteCount/2)
+	if requestCount > len(values) {
+		return nil, common.ErrInvalidPacket
+	}
+	for i := 0; i < len(values); i++ {
+		values[i] = uint16(b[1+2*i])<<8 | uint16(b[2+2*i])
+	}
+	return &ReadWriteMultipleRegistersResponse{
+		values: values[:requestCount],
+	}, nil
+}
+
+func newReadFIFOQueueResponse(b []byte) (*ReadFIFOQueueResponse, error) {
+	if len(b) < 4 {
+		return nil, common.ErrInvalidPacket
+	}
+	byteCount := int(b[0])<<8 | int(b[1])
+	fifoCount := int(b[2])<<8 | int(b[3])
+	// The byte count covers the FIFO count and the values, and a queue can't hold more than 31 values
+	if byteCount != len(b)-2 || byteCount != 2+fifoCount*2 || fifoCount > MaxFIFOCount {
+		return nil, common.ErrInvalidPacket
+	}
+	queue := make([]uint16, fifoCount)
+	for i := range queue {
+		queue[i] = uint16(b[4+i*2])<<8 | uint16(b[5+i*2])
+	}
+	return &ReadFIFOQueueResponse{
+		queue: queue,
+	}, nil
+}
+
+func newReadDeviceIdentificationResponse(b []byte) (*ReadDeviceIdentificationResponse, error) {
+	if len(b) < 6 {
+		return nil, common.ErrInvalidPacket
+	}
+	if MEIType(b[0]) != ReadDeviceIdentificationMEI {
+		return nil, common.ErrInvalidPacket
+	}
+	objectCount := int(b[5])
+	objects := make([]DeviceIdentificationObject, 0, objectCount)
+	pos := 6
+	for i := 0; i < objectCount; i++ {
+		if len(b) < pos+2 {
+			return nil, common.ErrInvalidPacket
+		}
+		length := int(b[pos+1])
+		if len(b) < pos+2+length {
+			return nil, common.ErrInvalidPacket
+		}
+		value := make([]byte, length)
+		copy(value, b[pos+2:pos+2+length])
+		objects = append(objects, DeviceIdentificationObject{ID: DeviceObjectID(b[pos]), Value: value})
+		pos += 2 + length
+	}
+	if pos != len(b) {
+		return nil, common.ErrInvalidPacket
+	}
+	return &ReadDeviceIdentificationResponse{
+		code:            DeviceIDCode(b[1]),
+		conformityLevel: b[2],
+		moreFollows:     b[3] == 0xFF,
+		nextObjectID:    DeviceObjectID(b[4]),
+		objects:         objects,
+	}, nil
+}
+
 func NewModbusOperationExceptionFromResponse(functionCode FunctionCode, b []byte) (*ModbusOperationException, error) {
 	if len(b) != 1 {
 		return nil, common.ErrInvalidPacket
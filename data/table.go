@@ -0,0 +1,31 @@
+package data
+
+// Table is one of the four tables of data in a device.
+type Table uint8
+
+const (
+	Coils Table = iota
+	DiscreteInputs
+	HoldingRegisters
+	InputRegisters
+)
+
+func (t Table) String() string {
+	switch t {
+	case Coils:
+		return "Coils"
+	case DiscreteInputs:
+		return "DiscreteInputs"
+	case HoldingRegisters:
+		return "HoldingRegisters"
+	case InputRegisters:
+		return "InputRegisters"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsBits reports whether the table holds single bits rather than 16 bit registers.
+func (t Table) IsBits() bool {
+	return t == Coils || t == DiscreteInputs
+}
@@ -0,0 +1,208 @@
+// Package discovery finds the devices on a serial bus by asking every unit address in turn whether anything is there.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	sp "github.com/goburrow/serial"
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	settings "github.com/rinzlerlabs/gomodbus/settings/serial"
+	"github.com/rinzlerlabs/gomodbus/transport"
+	"github.com/rinzlerlabs/gomodbus/transport/serial/ascii"
+	"github.com/rinzlerlabs/gomodbus/transport/serial/rtu"
+	"go.uber.org/zap"
+)
+
+const (
+	// MinAddress and MaxAddress are the unit addresses a serial device can have, 0 is broadcast and the ones above
+	// MaxAddress are reserved.
+	MinAddress = 1
+	MaxAddress = 247
+	// DefaultTimeout is how long each address is given to answer when Options.Timeout is 0.
+	DefaultTimeout = 100 * time.Millisecond
+)
+
+// Options controls which addresses and serial settings are scanned and how each address is probed.
+type Options struct {
+	// FirstAddress and LastAddress are the range of unit addresses probed, MinAddress to MaxAddress when both are 0.
+	FirstAddress uint16
+	LastAddress  uint16
+	// Timeout is how long to wait for each address to answer, DefaultTimeout when it is 0.
+	Timeout time.Duration
+	// Probe is the read sent to every address, the Address of the item is ignored. It reads holding register 0 when its
+	// Count is 0.
+	Probe client.ReadItem
+	// ReportServerID asks every address for its server ID first, falling back to Probe when that isn't answered with
+	// one. Devices that don't support it answer with an exception or not at all, so it slows down scans of empty
+	// addresses.
+	ReportServerID bool
+	// BaudRates and Parities are swept, every address is scanned with every combination of them. The baud rate and
+	// parity of the settings are used when they are empty.
+	BaudRates []int
+	Parities  []string
+	// OnResult is called with every address that answers as soon as it does, for showing progress during long scans.
+	OnResult func(Result)
+}
+
+// Result is an address that answered.
+type Result struct {
+	// Settings is the serial settings the device answered with.
+	Settings settings.SerialSettings
+	Address  uint16
+	// ServerID and RunIndicator are the answer to Report Server ID, when it was asked for and the device supports it.
+	ServerID     []byte
+	RunIndicator bool
+	// Bits or Registers are the answer to the probe.
+	Bits      []bool
+	Registers []uint16
+	// Exception is set when the device answered with an exception, which still shows there is a device there.
+	Exception *data.ModbusException
+	// Err is set when something answered but the response couldn't be understood, like a bad checksum. It is usually a
+	// device using different serial settings or two devices sharing an address.
+	Err error
+}
+
+// Scan probes every address on the serial port of s and returns the ones that answered, in the order they were found.
+// When the context is cancelled the results found so far are returned with the context's error.
+func Scan(ctx context.Context, logger *zap.Logger, s settings.SerialSettings, options Options) ([]Result, error) {
+	return scan(ctx, logger, s, options, func(config *sp.Config) (io.ReadWriteCloser, error) {
+		return sp.Open(config)
+	})
+}
+
+func scan(ctx context.Context, logger *zap.Logger, s settings.SerialSettings, options Options, open func(*sp.Config) (io.ReadWriteCloser, error)) ([]Result, error) {
+	if options.FirstAddress == 0 && options.LastAddress == 0 {
+		options.FirstAddress, options.LastAddress = MinAddress, MaxAddress
+	}
+	if options.FirstAddress < MinAddress || options.LastAddress > MaxAddress || options.FirstAddress > options.LastAddress {
+		return nil, common.ErrInvalidValue
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.Probe.Count == 0 {
+		options.Probe = client.ReadItem{Table: data.HoldingRegisters, Offset: 0, Count: 1}
+	}
+	if options.Probe.Table > data.InputRegisters {
+		return nil, common.ErrInvalidValue
+	}
+	bauds := options.BaudRates
+	if len(bauds) == 0 {
+		bauds = []int{s.Baud}
+	}
+	parities := options.Parities
+	if len(parities) == 0 {
+		parities = []string{s.Parity}
+	}
+
+	var results []Result
+	for _, baud := range bauds {
+		for _, parity := range parities {
+			s.Baud = baud
+			s.Parity = parity
+			found, err := scanPort(ctx, logger, s, options, open)
+			results = append(results, found...)
+			if err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// scanPort probes every address with one set of serial settings
+func scanPort(ctx context.Context, logger *zap.Logger, s settings.SerialSettings, options Options, open func(*sp.Config) (io.ReadWriteCloser, error)) ([]Result, error) {
+	config := s.GetSerialPortConfig()
+	// The port gives up on a read before the transport does, so a read left behind by a timeout can't take the bytes
+	// of the next address's response
+	config.Timeout = options.Timeout
+	port, err := open(config)
+	if err != nil {
+		return nil, err
+	}
+	var t transport.Transport
+	switch s.Transport {
+	case settings.ASCII:
+		t = ascii.NewModbusClientTransport(port, logger, 2*options.Timeout)
+	default:
+		t = rtu.NewModbusClientTransport(port, logger, 2*options.Timeout)
+	}
+	c := client.NewModbusClient(ctx, logger, t)
+	defer c.Close()
+
+	logger.Info("Scanning serial bus", zap.String("device", s.Device), zap.Int("baud", s.Baud), zap.String("parity", s.Parity))
+	var results []Result
+	for address := int(options.FirstAddress); address <= int(options.LastAddress); address++ {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result, answered := probe(ctx, c, uint16(address), options)
+		if !answered {
+			continue
+		}
+		result.Settings = s
+		logger.Debug("Address answered", zap.Int("address", address), zap.Any("exception", result.Exception), zap.Error(result.Err))
+		results = append(results, result)
+		if options.OnResult != nil {
+			options.OnResult(result)
+		}
+	}
+	return results, ctx.Err()
+}
+
+// probe asks one address whether there is a device there
+func probe(ctx context.Context, c client.ModbusClient, address uint16, options Options) (Result, bool) {
+	result := Result{Address: address}
+	var serverIDErr error
+	if options.ReportServerID {
+		serverID, runIndicator, err := c.ReportServerIDContext(ctx, address)
+		if err == nil {
+			result.ServerID = serverID
+			result.RunIndicator = runIndicator
+			return result, true
+		}
+		serverIDErr = err
+	}
+
+	var err error
+	switch options.Probe.Table {
+	case data.Coils:
+		result.Bits, err = c.ReadCoilsContext(ctx, address, options.Probe.Offset, options.Probe.Count)
+	case data.DiscreteInputs:
+		result.Bits, err = c.ReadDiscreteInputsContext(ctx, address, options.Probe.Offset, options.Probe.Count)
+	case data.HoldingRegisters:
+		result.Registers, err = c.ReadHoldingRegistersContext(ctx, address, options.Probe.Offset, options.Probe.Count)
+	case data.InputRegisters:
+		result.Registers, err = c.ReadInputRegistersContext(ctx, address, options.Probe.Offset, options.Probe.Count)
+	}
+	if err == nil {
+		return result, true
+	}
+	if ctx.Err() != nil {
+		return result, false
+	}
+	// A device that only answered Report Server ID, with an exception, is still there
+	if !answered(err) && serverIDErr != nil && answered(serverIDErr) {
+		err = serverIDErr
+	}
+	if !answered(err) {
+		return result, false
+	}
+	var exception *data.ModbusException
+	if errors.As(err, &exception) {
+		result.Exception = exception
+	} else {
+		result.Err = err
+	}
+	return result, true
+}
+
+// answered reports whether an error came from something on the bus rather than from nothing answering
+func answered(err error) bool {
+	return !errors.Is(err, common.ErrTimeout) && !errors.Is(err, sp.ErrTimeout)
+}
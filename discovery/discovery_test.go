@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	sp "github.com/goburrow/serial"
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	settings "github.com/rinzlerlabs/gomodbus/settings/serial"
+	"github.com/rinzlerlabs/gomodbus/transport"
+	"github.com/rinzlerlabs/gomodbus/transport/serial"
+	"github.com/rinzlerlabs/gomodbus/transport/serial/rtu"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeDevice struct {
+	baud      int
+	parity    string
+	registers []uint16
+	exception data.ExceptionCode
+	serverID  []byte
+	garbled   bool
+}
+
+// fakeBus is a serial port with RTU devices behind it, each only answering with its own baud rate and parity
+type fakeBus struct {
+	mu       sync.Mutex
+	config   *sp.Config
+	devices  map[uint16]fakeDevice
+	readData []byte
+	probed   []uint16
+}
+
+func (b *fakeBus) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if len(b.readData) == 0 {
+		timeout := b.config.Timeout
+		b.mu.Unlock()
+		time.Sleep(timeout)
+		return 0, sp.ErrTimeout
+	}
+	defer b.mu.Unlock()
+	n := copy(p, b.readData)
+	b.readData = b.readData[n:]
+	return n, nil
+}
+
+func (b *fakeBus) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	address := uint16(p[0])
+	functionCode := data.FunctionCode(p[1])
+	b.probed = append(b.probed, address)
+	device, ok := b.devices[address]
+	if !ok || device.baud != b.config.BaudRate || device.parity != b.config.Parity {
+		return len(p), nil
+	}
+	var op data.ModbusOperation
+	switch {
+	case functionCode == data.ReportServerID && device.serverID != nil:
+		op = data.NewReportServerIDResponse(device.serverID, true)
+	case functionCode == data.ReportServerID:
+		op = data.NewModbusOperationException(functionCode, data.IllegalFunction)
+	case device.exception != 0:
+		op = data.NewModbusOperationException(functionCode, device.exception)
+	default:
+		op = data.NewReadHoldingRegistersResponse(device.registers)
+	}
+	adu, err := rtu.NewModbusApplicationDataUnit(serial.NewHeader(address), transport.NewProtocolDataUnit(op))
+	if err != nil {
+		return 0, err
+	}
+	b.readData = adu.Bytes()
+	if device.garbled {
+		b.readData[len(b.readData)-1] ^= 0xFF
+	}
+	return len(p), nil
+}
+
+func (b *fakeBus) Close() error {
+	return nil
+}
+
+func (b *fakeBus) open(config *sp.Config) (io.ReadWriteCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config = config
+	b.readData = nil
+	return b, nil
+}
+
+func TestScan(t *testing.T) {
+	bus := &fakeBus{devices: map[uint16]fakeDevice{
+		3:  {baud: 9600, parity: "N", registers: []uint16{0x1234}},
+		7:  {baud: 9600, parity: "N", exception: data.IllegalDataAddress},
+		9:  {baud: 9600, parity: "N", registers: []uint16{1}, garbled: true},
+		12: {baud: 19200, parity: "E", registers: []uint16{0x0042}},
+	}}
+	s := settings.SerialSettings{Transport: settings.RTU, Device: "/dev/ttyUSB0", Baud: 9600, DataBits: 8, Parity: "N", StopBits: 1}
+	var reported []uint16
+	results, err := scan(context.Background(), zap.NewNop(), s, Options{
+		FirstAddress: 1,
+		LastAddress:  15,
+		Timeout:      5 * time.Millisecond,
+		BaudRates:    []int{9600, 19200},
+		Parities:     []string{"N", "E"},
+		OnResult: func(r Result) {
+			reported = append(reported, r.Address)
+		},
+	}, bus.open)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{3, 7, 9, 12}, reported)
+	if !assert.Len(t, results, 4) {
+		return
+	}
+
+	assert.Equal(t, uint16(3), results[0].Address)
+	assert.Equal(t, 9600, results[0].Settings.Baud)
+	assert.Equal(t, []uint16{0x1234}, results[0].Registers)
+	assert.Nil(t, results[0].Exception)
+	assert.NoError(t, results[0].Err)
+
+	assert.Equal(t, data.IllegalDataAddress, results[1].Exception.ExceptionCode)
+	assert.Equal(t, uint16(7), results[1].Exception.Address)
+
+	assert.ErrorIs(t, results[2].Err, common.ErrInvalidChecksum)
+
+	assert.Equal(t, uint16(12), results[3].Address)
+	assert.Equal(t, 19200, results[3].Settings.Baud)
+	assert.Equal(t, "E", results[3].Settings.Parity)
+	assert.Equal(t, []uint16{0x0042}, results[3].Registers)
+
+	// Every address is probed once with every combination of settings
+	assert.Len(t, bus.probed, 4*15)
+}
+
+func TestScanReportServerID(t *testing.T) {
+	bus := &fakeBus{devices: map[uint16]fakeDevice{
+		1: {baud: 9600, parity: "E", serverID: []byte("PUMP-01")},
+		2: {baud: 9600, parity: "E", registers: []uint16{5}},
+	}}
+	s := settings.SerialSettings{Transport: settings.RTU, Baud: 9600, DataBits: 8, Parity: "E", StopBits: 1}
+	results, err := scan(context.Background(), zap.NewNop(), s, Options{FirstAddress: 1, LastAddress: 3, Timeout: 5 * time.Millisecond, ReportServerID: true}, bus.open)
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, []byte("PUMP-01"), results[0].ServerID)
+	assert.True(t, results[0].RunIndicator)
+	assert.Nil(t, results[0].Registers)
+	// Devices that don't support Report Server ID fall back to the read
+	assert.Nil(t, results[1].ServerID)
+	assert.Equal(t, []uint16{5}, results[1].Registers)
+}
+
+func TestScanOptions(t *testing.T) {
+	bus := &fakeBus{}
+	s := settings.SerialSettings{Transport: settings.RTU, Baud: 9600, Parity: "N"}
+	_, err := scan(context.Background(), zap.NewNop(), s, Options{FirstAddress: 0, LastAddress: 10}, bus.open)
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+	_, err = scan(context.Background(), zap.NewNop(), s, Options{FirstAddress: 10, LastAddress: 248}, bus.open)
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+	_, err = scan(context.Background(), zap.NewNop(), s, Options{FirstAddress: 10, LastAddress: 5}, bus.open)
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+	_, err = scan(context.Background(), zap.NewNop(), s, Options{Probe: client.ReadItem{Table: data.Table(9), Count: 1}}, bus.open)
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := scan(ctx, zap.NewNop(), s, Options{}, bus.open)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,319 @@
+// Package poller reads groups of coils and registers from devices on a schedule and tells subscribers when their values
+// or the quality of the reads change.
+package poller
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"go.uber.org/zap"
+)
+
+// Quality is how much the current values of a group can be trusted.
+type Quality int
+
+const (
+	// Unknown is the quality of a group that hasn't been read yet.
+	Unknown Quality = iota
+	// Good means the last read of the group succeeded.
+	Good
+	// Stale means the last read failed, the values are from an earlier read that is younger than the group's StaleAfter.
+	Stale
+	// Bad means the group hasn't been read successfully for StaleAfter, or ever.
+	Bad
+)
+
+func (q Quality) String() string {
+	switch q {
+	case Unknown:
+		return "Unknown"
+	case Good:
+		return "Good"
+	case Stale:
+		return "Stale"
+	case Bad:
+		return "Bad"
+	default:
+		return "Invalid"
+	}
+}
+
+// Group is a range of coils or registers in a device that is read every Interval.
+type Group struct {
+	// Name identifies the group in events, it must be unique within a poller.
+	Name    string
+	Address uint16
+	Table   data.Table
+	Offset  uint16
+	Count   uint16
+	// Interval is the time between the start of one read and the next. When a read takes longer the reads that should
+	// have started in the meantime are skipped rather than run back to back.
+	Interval time.Duration
+	// StaleAfter is how long after the last successful read failed reads stop reporting Stale and start reporting Bad,
+	// 3 times the interval when it is 0.
+	StaleAfter time.Duration
+}
+
+// Values is the coils or discrete inputs, or the registers, of a group.
+type Values struct {
+	Bits      []bool
+	Registers []uint16
+}
+
+// Event reports a change in the values or the quality of a group.
+type Event struct {
+	Group     string
+	Address   uint16
+	Table     data.Table
+	Offset    uint16
+	Timestamp time.Time
+	// Old and New are the values of the group before and after the read, Changed is the index of every value that
+	// differs between them. On the first successful read every value has changed.
+	Old     Values
+	New     Values
+	Changed []int
+	// OldQuality and Quality are the quality of the group before and after the read, Err is why the read failed when
+	// Quality isn't Good.
+	OldQuality Quality
+	Quality    Quality
+	Err        error
+}
+
+type subscription struct {
+	handler func(Event)
+}
+
+type groupState struct {
+	group    Group
+	cancel   context.CancelFunc
+	values   Values
+	quality  Quality
+	lastGood time.Time
+}
+
+// Poller reads its groups on their schedules for as long as it is open.
+type Poller struct {
+	logger        *zap.Logger
+	client        client.ModbusClient
+	ctx           context.Context
+	cancel        context.CancelFunc
+	mu            sync.Mutex
+	groups        map[string]*groupState
+	subscribersMu sync.RWMutex
+	subscribers   []*subscription
+	wg            sync.WaitGroup
+}
+
+// NewPoller creates a poller that reads from a client until ctx is cancelled or it is closed.
+func NewPoller(ctx context.Context, logger *zap.Logger, c client.ModbusClient) *Poller {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Poller{
+		logger: logger,
+		client: c,
+		ctx:    ctx,
+		cancel: cancel,
+		groups: make(map[string]*groupState),
+	}
+}
+
+// AddGroup starts reading a group, the first read starts straight away.
+func (p *Poller) AddGroup(group Group) error {
+	if group.Name == "" || group.Interval <= 0 || group.Table > data.InputRegisters {
+		return common.ErrInvalidValue
+	}
+	if group.Count == 0 || int(group.Offset)+int(group.Count) > 0x10000 {
+		return common.ErrInvalidCount
+	}
+	if group.StaleAfter == 0 {
+		group.StaleAfter = 3 * group.Interval
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		return common.ErrPollerClosed
+	}
+	if _, ok := p.groups[group.Name]; ok {
+		return common.ErrInvalidValue
+	}
+	ctx, cancel := context.WithCancel(p.ctx)
+	state := &groupState{group: group, cancel: cancel}
+	p.groups[group.Name] = state
+	p.wg.Add(1)
+	go p.run(ctx, state)
+	return nil
+}
+
+// RemoveGroup stops reading a group, it is a no-op if there is no group with that name.
+func (p *Poller) RemoveGroup(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if state, ok := p.groups[name]; ok {
+		state.cancel()
+		delete(p.groups, name)
+	}
+}
+
+// Subscribe calls handler with every event until the returned function is called. Handlers are called from the
+// goroutine that reads the group, so they are called concurrently for different groups and a slow handler delays the
+// next read of its group.
+func (p *Poller) Subscribe(handler func(Event)) (unsubscribe func()) {
+	s := &subscription{handler: handler}
+	p.subscribersMu.Lock()
+	p.subscribers = append(p.subscribers, s)
+	p.subscribersMu.Unlock()
+	return func() {
+		p.subscribersMu.Lock()
+		defer p.subscribersMu.Unlock()
+		p.subscribers = slices.DeleteFunc(p.subscribers, func(other *subscription) bool {
+			return other == s
+		})
+	}
+}
+
+// SubscribeChannel delivers every event to a channel with room for size events until the returned function is called,
+// which closes the channel. Reads wait for room in the channel, so it needs to be drained.
+func (p *Poller) SubscribeChannel(size int) (events <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, size)
+	done := make(chan struct{})
+	// mu keeps the channel from being closed while an event is being sent to it
+	var mu sync.Mutex
+	closed := false
+	stop := p.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- e:
+		case <-done:
+		case <-p.ctx.Done():
+		}
+	})
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			stop()
+			// Unblock a send that is waiting for room before taking the lock
+			close(done)
+			mu.Lock()
+			closed = true
+			mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Close stops every group and waits for the reads in progress to finish. It doesn't close the client.
+func (p *Poller) Close() error {
+	p.mu.Lock()
+	p.cancel()
+	p.mu.Unlock()
+	p.wg.Wait()
+	return nil
+}
+
+func (p *Poller) run(ctx context.Context, state *groupState) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(state.group.Interval)
+	defer ticker.Stop()
+	for {
+		p.poll(ctx, state)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, state *groupState) {
+	group := state.group
+	values, err := p.read(ctx, group)
+	if ctx.Err() != nil {
+		// The group was removed or the poller closed while reading
+		return
+	}
+	now := time.Now()
+	event := Event{
+		Group:      group.Name,
+		Address:    group.Address,
+		Table:      group.Table,
+		Offset:     group.Offset,
+		Timestamp:  now,
+		Old:        state.values,
+		OldQuality: state.quality,
+	}
+	if err != nil {
+		quality := Bad
+		if !state.lastGood.IsZero() && now.Sub(state.lastGood) < group.StaleAfter {
+			quality = Stale
+		}
+		p.logger.Debug("Failed to poll group", zap.String("group", group.Name), zap.Stringer("quality", quality), zap.Error(err))
+		if quality == state.quality {
+			return
+		}
+		state.quality = quality
+		event.New = state.values
+		event.Quality = quality
+		event.Err = err
+	} else {
+		changed := changes(state.values, values)
+		state.lastGood = now
+		if len(changed) == 0 && state.quality == Good {
+			return
+		}
+		state.values = values
+		state.quality = Good
+		event.New = values
+		event.Changed = changed
+		event.Quality = Good
+	}
+	p.publish(event)
+}
+
+func (p *Poller) read(ctx context.Context, group Group) (Values, error) {
+	var values Values
+	var err error
+	switch group.Table {
+	case data.Coils:
+		values.Bits, err = p.client.ReadCoilsContext(ctx, group.Address, group.Offset, group.Count)
+	case data.DiscreteInputs:
+		values.Bits, err = p.client.ReadDiscreteInputsContext(ctx, group.Address, group.Offset, group.Count)
+	case data.HoldingRegisters:
+		values.Registers, err = p.client.ReadHoldingRegistersContext(ctx, group.Address, group.Offset, group.Count)
+	case data.InputRegisters:
+		values.Registers, err = p.client.ReadInputRegistersContext(ctx, group.Address, group.Offset, group.Count)
+	}
+	return values, err
+}
+
+// changes returns the index of every value that differs, every value is new when there were no values before
+func changes(old, new Values) []int {
+	var changed []int
+	for i := range new.Bits {
+		if i >= len(old.Bits) || old.Bits[i] != new.Bits[i] {
+			changed = append(changed, i)
+		}
+	}
+	for i := range new.Registers {
+		if i >= len(old.Registers) || old.Registers[i] != new.Registers[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+func (p *Poller) publish(event Event) {
+	p.subscribersMu.RLock()
+	subscribers := slices.Clone(p.subscribers)
+	p.subscribersMu.RUnlock()
+	for _, s := range subscribers {
+		s.handler(event)
+	}
+}
@@ -0,0 +1,143 @@
+package poller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/client"
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type readResult struct {
+	registers []uint16
+	err       error
+}
+
+// scriptedClient answers each read with the next result sent to it
+type scriptedClient struct {
+	client.ModbusClient
+	results chan readResult
+}
+
+func (c *scriptedClient) ReadHoldingRegistersContext(ctx context.Context, address, offset, quantity uint16) ([]uint16, error) {
+	select {
+	case r := <-c.results:
+		return r.registers, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestPoller(t *testing.T) {
+	c := &scriptedClient{results: make(chan readResult)}
+	p := NewPoller(context.Background(), zap.NewNop(), c)
+	defer p.Close()
+	events, unsubscribe := p.SubscribeChannel(1)
+	defer unsubscribe()
+	assert.NoError(t, p.AddGroup(Group{Name: "pump", Address: 1, Table: data.HoldingRegisters, Offset: 10, Count: 2, Interval: time.Millisecond, StaleAfter: time.Hour}))
+
+	c.results <- readResult{registers: []uint16{1, 2}}
+	e := nextEvent(t, events)
+	assert.Equal(t, "pump", e.Group)
+	assert.Equal(t, uint16(10), e.Offset)
+	assert.Equal(t, Unknown, e.OldQuality)
+	assert.Equal(t, Good, e.Quality)
+	assert.Equal(t, []uint16{1, 2}, e.New.Registers)
+	assert.Equal(t, []int{0, 1}, e.Changed)
+
+	// Unchanged values don't make an event
+	c.results <- readResult{registers: []uint16{1, 2}}
+	c.results <- readResult{registers: []uint16{1, 3}}
+	e = nextEvent(t, events)
+	assert.Equal(t, []uint16{1, 2}, e.Old.Registers)
+	assert.Equal(t, []uint16{1, 3}, e.New.Registers)
+	assert.Equal(t, []int{1}, e.Changed)
+	assert.Equal(t, Good, e.Quality)
+
+	// A failed read keeps the values but marks them stale, once
+	c.results <- readResult{err: common.ErrTimeout}
+	e = nextEvent(t, events)
+	assert.Equal(t, Good, e.OldQuality)
+	assert.Equal(t, Stale, e.Quality)
+	assert.ErrorIs(t, e.Err, common.ErrTimeout)
+	assert.Equal(t, []uint16{1, 3}, e.New.Registers)
+	assert.Empty(t, e.Changed)
+	c.results <- readResult{err: common.ErrTimeout}
+	c.results <- readResult{registers: []uint16{1, 3}}
+	e = nextEvent(t, events)
+	assert.Equal(t, Stale, e.OldQuality)
+	assert.Equal(t, Good, e.Quality)
+	assert.Empty(t, e.Changed)
+	assert.NoError(t, e.Err)
+}
+
+func TestPollerBadQuality(t *testing.T) {
+	c := &scriptedClient{results: make(chan readResult)}
+	p := NewPoller(context.Background(), zap.NewNop(), c)
+	defer p.Close()
+	var received []Event
+	done := make(chan struct{})
+	unsubscribe := p.Subscribe(func(e Event) {
+		received = append(received, e)
+		if len(received) == 4 {
+			close(done)
+		}
+	})
+	defer unsubscribe()
+	assert.NoError(t, p.AddGroup(Group{Name: "meter", Address: 2, Table: data.HoldingRegisters, Offset: 0, Count: 1, Interval: time.Millisecond, StaleAfter: 200 * time.Millisecond}))
+
+	// Never read successfully, so there are no values to be stale
+	c.results <- readResult{err: common.ErrServerDeviceBusy}
+	c.results <- readResult{registers: []uint16{7}}
+	c.results <- readResult{err: common.ErrTimeout}
+	// Failures turn into Bad once the values are older than StaleAfter
+	time.Sleep(250 * time.Millisecond)
+	c.results <- readResult{err: common.ErrTimeout}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+	assert.Equal(t, Bad, received[0].Quality)
+	assert.ErrorIs(t, received[0].Err, common.ErrServerDeviceBusy)
+	assert.Equal(t, Good, received[1].Quality)
+	assert.Equal(t, Stale, received[2].Quality)
+	assert.Equal(t, Stale, received[3].OldQuality)
+	assert.Equal(t, Bad, received[3].Quality)
+	assert.Equal(t, []uint16{7}, received[3].New.Registers)
+
+	// Removing the group stops the read that is waiting
+	p.RemoveGroup("meter")
+	assert.NoError(t, p.Close())
+	assert.Len(t, received, 4)
+}
+
+func TestAddGroup(t *testing.T) {
+	c := &scriptedClient{results: make(chan readResult)}
+	p := NewPoller(context.Background(), zap.NewNop(), c)
+	group := Group{Name: "a", Table: data.HoldingRegisters, Count: 1, Interval: time.Second}
+	assert.NoError(t, p.AddGroup(group))
+	assert.ErrorIs(t, p.AddGroup(group), common.ErrInvalidValue)
+	assert.ErrorIs(t, p.AddGroup(Group{Name: "b", Table: data.HoldingRegisters, Count: 1}), common.ErrInvalidValue)
+	assert.ErrorIs(t, p.AddGroup(Group{Name: "c", Table: data.HoldingRegisters, Interval: time.Second}), common.ErrInvalidCount)
+	assert.ErrorIs(t, p.AddGroup(Group{Name: "d", Table: data.Coils, Offset: 0xFFFF, Count: 2, Interval: time.Second}), common.ErrInvalidCount)
+
+	// Close stops the read that is waiting
+	assert.NoError(t, p.Close())
+	assert.ErrorIs(t, p.AddGroup(Group{Name: "e", Table: data.HoldingRegisters, Count: 1, Interval: time.Second}), common.ErrPollerClosed)
+}
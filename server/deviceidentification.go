@@ -0,0 +1,132 @@
+package server
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+const (
+	// DefaultVendorName is the VendorName object returned by the DefaultHandler.
+	DefaultVendorName = "rinzlerlabs"
+	// DefaultProductCode is the ProductCode object returned by the DefaultHandler.
+	DefaultProductCode = "gomodbus"
+	// DefaultMajorMinorRevision is the MajorMinorRevision object returned by the DefaultHandler.
+	DefaultMajorMinorRevision = "1.0"
+
+	// The response PDU is limited to 253 bytes, 7 of which are used by the function code and the Read Device Identification header
+	maxDeviceIdentificationObjectBytes = 253 - 7
+	// Each object has a 1 byte id and a 1 byte length
+	maxDeviceIdentificationObjectLength = maxDeviceIdentificationObjectBytes - 2
+)
+
+// DeviceIdentification is a thread safe store for the objects returned by the Read Device Identification function.
+type DeviceIdentification struct {
+	mu      sync.RWMutex
+	objects map[data.DeviceObjectID][]byte
+}
+
+// NewDeviceIdentification creates a new DeviceIdentification populated with the mandatory basic objects.
+func NewDeviceIdentification(vendorName, productCode, majorMinorRevision string) *DeviceIdentification {
+	return &DeviceIdentification{
+		objects: map[data.DeviceObjectID][]byte{
+			data.VendorName:         []byte(vendorName),
+			data.ProductCode:        []byte(productCode),
+			data.MajorMinorRevision: []byte(majorMinorRevision),
+		},
+	}
+}
+
+// SetObject sets the value of an object, objects 0x80 through 0xFF are private and may contain any data.
+func (d *DeviceIdentification) SetObject(id data.DeviceObjectID, value []byte) error {
+	if len(value) > maxDeviceIdentificationObjectLength {
+		return common.ErrInvalidValue
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.objects[id] = append([]byte{}, value...)
+	return nil
+}
+
+// Object returns the value of an object and whether it exists.
+func (d *DeviceIdentification) Object(id data.DeviceObjectID) ([]byte, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	value, ok := d.objects[id]
+	return value, ok
+}
+
+// ConformityLevel returns the conformity level of the device, individual access is always supported.
+func (d *DeviceIdentification) ConformityLevel() byte {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.conformityLevel()
+}
+
+func (d *DeviceIdentification) conformityLevel() byte {
+	level := byte(data.BasicDeviceIdentification)
+	for id := range d.objects {
+		if id >= 0x80 {
+			level = byte(data.ExtendedDeviceIdentification)
+			break
+		} else if id > data.MajorMinorRevision {
+			level = byte(data.RegularDeviceIdentification)
+		}
+	}
+	return 0x80 | level
+}
+
+// Read builds the response to a Read Device Identification request.
+func (d *DeviceIdentification) Read(code data.DeviceIDCode, objectID data.DeviceObjectID) (*data.ReadDeviceIdentificationResponse, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var last data.DeviceObjectID
+	switch code {
+	case data.BasicDeviceIdentification:
+		last = data.MajorMinorRevision
+	case data.RegularDeviceIdentification:
+		last = 0x7F
+	case data.ExtendedDeviceIdentification:
+		last = 0xFF
+	case data.SpecificDeviceIdentification:
+		value, ok := d.objects[objectID]
+		if !ok {
+			return nil, common.ErrIllegalDataAddress
+		}
+		objects := []data.DeviceIdentificationObject{{ID: objectID, Value: value}}
+		return data.NewReadDeviceIdentificationResponse(code, d.conformityLevel(), false, 0x00, objects), nil
+	default:
+		return nil, common.ErrIllegalDataValue
+	}
+
+	ids := make([]data.DeviceObjectID, 0, len(d.objects))
+	for id := range d.objects {
+		if id <= last {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// If the requested object doesn't exist in this category, the spec says we restart from the beginning
+	start := 0
+	for i, id := range ids {
+		if id == objectID {
+			start = i
+			break
+		}
+	}
+
+	objects := make([]data.DeviceIdentificationObject, 0)
+	size := 0
+	for i := start; i < len(ids); i++ {
+		value := d.objects[ids[i]]
+		if size+2+len(value) > maxDeviceIdentificationObjectBytes {
+			return data.NewReadDeviceIdentificationResponse(code, d.conformityLevel(), true, ids[i], objects), nil
+		}
+		size += 2 + len(value)
+		objects = append(objects, data.DeviceIdentificationObject{ID: ids[i], Value: value})
+	}
+	return data.NewReadDeviceIdentificationResponse(code, d.conformityLevel(), false, 0x00, objects), nil
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceIdentificationRead(t *testing.T) {
+	tests := []struct {
+		name                    string
+		code                    data.DeviceIDCode
+		objectID                data.DeviceObjectID
+		extraObjects            map[data.DeviceObjectID][]byte
+		expectedObjects         []data.DeviceObjectID
+		expectedMoreFollows     bool
+		expectedNextObjectID    data.DeviceObjectID
+		expectedConformityLevel byte
+		expectedError           error
+	}{
+		{
+			name:                    "Basic",
+			code:                    data.BasicDeviceIdentification,
+			objectID:                data.VendorName,
+			expectedObjects:         []data.DeviceObjectID{data.VendorName, data.ProductCode, data.MajorMinorRevision},
+			expectedConformityLevel: 0x81,
+		},
+		{
+			name:                    "Basic_StartAtProductCode",
+			code:                    data.BasicDeviceIdentification,
+			objectID:                data.ProductCode,
+			expectedObjects:         []data.DeviceObjectID{data.ProductCode, data.MajorMinorRevision},
+			expectedConformityLevel: 0x81,
+		},
+		{
+			name:                    "Basic_UnknownObjectRestartsAtBeginning",
+			code:                    data.BasicDeviceIdentification,
+			objectID:                data.ModelName,
+			expectedObjects:         []data.DeviceObjectID{data.VendorName, data.ProductCode, data.MajorMinorRevision},
+			expectedConformityLevel: 0x81,
+		},
+		{
+			name:                    "Regular_ExcludesExtended",
+			code:                    data.RegularDeviceIdentification,
+			objectID:                data.VendorName,
+			extraObjects:            map[data.DeviceObjectID][]byte{data.ModelName: []byte("M"), 0x80: []byte("X")},
+			expectedObjects:         []data.DeviceObjectID{data.VendorName, data.ProductCode, data.MajorMinorRevision, data.ModelName},
+			expectedConformityLevel: 0x83,
+		},
+		{
+			name:                    "Extended_MoreFollows",
+			code:                    data.ExtendedDeviceIdentification,
+			objectID:                data.VendorName,
+			extraObjects:            map[data.DeviceObjectID][]byte{0x80: bytes.Repeat([]byte{0x01}, 200), 0x81: bytes.Repeat([]byte{0x02}, 200)},
+			expectedObjects:         []data.DeviceObjectID{data.VendorName, data.ProductCode, data.MajorMinorRevision, 0x80},
+			expectedMoreFollows:     true,
+			expectedNextObjectID:    0x81,
+			expectedConformityLevel: 0x83,
+		},
+		{
+			name:                    "Specific",
+			code:                    data.SpecificDeviceIdentification,
+			objectID:                data.ProductCode,
+			expectedObjects:         []data.DeviceObjectID{data.ProductCode},
+			expectedConformityLevel: 0x81,
+		},
+		{
+			name:          "Specific_UnknownObject",
+			code:          data.SpecificDeviceIdentification,
+			objectID:      data.ModelName,
+			expectedError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:          "InvalidDeviceIDCode",
+			code:          0x05,
+			objectID:      data.VendorName,
+			expectedError: common.ErrIllegalDataValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identification := NewDeviceIdentification("ABC", "P1", "1.0")
+			for id, value := range tt.extraObjects {
+				assert.NoError(t, identification.SetObject(id, value))
+			}
+			resp, err := identification.Read(tt.code, tt.objectID)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			ids := make([]data.DeviceObjectID, 0)
+			for _, o := range resp.Objects() {
+				ids = append(ids, o.ID)
+			}
+			assert.Equal(t, tt.expectedObjects, ids)
+			assert.Equal(t, tt.expectedMoreFollows, resp.MoreFollows())
+			assert.Equal(t, tt.expectedNextObjectID, resp.NextObjectID())
+			assert.Equal(t, tt.expectedConformityLevel, resp.ConformityLevel())
+		})
+	}
+}
+
+func TestDeviceIdentificationSetObjectTooLong(t *testing.T) {
+	identification := NewDeviceIdentification("ABC", "P1", "1.0")
+	err := identification.SetObject(0x80, make([]byte, 245))
+	assert.Equal(t, common.ErrInvalidValue, err)
+}
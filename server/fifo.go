@@ -0,0 +1,89 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// FIFOQueues is a thread safe store for the queues returned by the Read FIFO Queue function, keyed by their pointer address.
+type FIFOQueues struct {
+	mu     sync.RWMutex
+	queues map[uint16][]uint16
+}
+
+// NewFIFOQueues creates a new FIFOQueues with no queues registered.
+func NewFIFOQueues() *FIFOQueues {
+	return &FIFOQueues{
+		queues: make(map[uint16][]uint16),
+	}
+}
+
+// Register creates an empty queue at the pointer address, registering an existing queue leaves it untouched.
+func (f *FIFOQueues) Register(pointer uint16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.queues[pointer]; !ok {
+		f.queues[pointer] = make([]uint16, 0, data.MaxFIFOCount)
+	}
+}
+
+// Unregister removes the queue at the pointer address.
+func (f *FIFOQueues) Unregister(pointer uint16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.queues, pointer)
+}
+
+// Push adds values to the end of the queue at the pointer address. A queue holds at most 31 values, once it is full the oldest
+// values are dropped to make room.
+func (f *FIFOQueues) Push(pointer uint16, values ...uint16) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	queue, ok := f.queues[pointer]
+	if !ok {
+		return common.ErrInvalidAddress
+	}
+	queue = append(queue, values...)
+	if len(queue) > data.MaxFIFOCount {
+		queue = append(queue[:0], queue[len(queue)-data.MaxFIFOCount:]...)
+	}
+	f.queues[pointer] = queue
+	return nil
+}
+
+// Pop removes and returns the oldest value in the queue at the pointer address, it returns false if the queue is empty.
+func (f *FIFOQueues) Pop(pointer uint16) (uint16, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	queue := f.queues[pointer]
+	if len(queue) == 0 {
+		return 0, false
+	}
+	value := queue[0]
+	f.queues[pointer] = append(queue[:0], queue[1:]...)
+	return value, true
+}
+
+// Clear removes all of the values from the queue at the pointer address.
+func (f *FIFOQueues) Clear(pointer uint16) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.queues[pointer]; !ok {
+		return common.ErrInvalidAddress
+	}
+	f.queues[pointer] = f.queues[pointer][:0]
+	return nil
+}
+
+// Read builds the response to a Read FIFO Queue request, like the spec says the queue is not cleared by reading it.
+func (f *FIFOQueues) Read(pointer uint16) (*data.ReadFIFOQueueResponse, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	queue, ok := f.queues[pointer]
+	if !ok {
+		return nil, common.ErrIllegalDataAddress
+	}
+	return data.NewReadFIFOQueueResponse(append([]uint16{}, queue...)), nil
+}
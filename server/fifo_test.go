@@ -0,0 +1,80 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFIFOQueuesRead(t *testing.T) {
+	tests := []struct {
+		name          string
+		register      bool
+		push          []uint16
+		expected      []uint16
+		expectedError error
+	}{
+		{
+			name:     "Empty",
+			register: true,
+			expected: []uint16{},
+		},
+		{
+			name:     "Values",
+			register: true,
+			push:     []uint16{0x01B8, 0x1284},
+			expected: []uint16{0x01B8, 0x1284},
+		},
+		{
+			name:     "Full_DropsOldest",
+			register: true,
+			push:     []uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32},
+			expected: []uint16{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32},
+		},
+		{
+			name:          "NotRegistered",
+			expectedError: common.ErrIllegalDataAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queues := NewFIFOQueues()
+			if tt.register {
+				queues.Register(0x04DE)
+				assert.NoError(t, queues.Push(0x04DE, tt.push...))
+			}
+			resp, err := queues.Read(0x04DE)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.Queue())
+			// Reading doesn't clear the queue
+			resp, err = queues.Read(0x04DE)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.Queue())
+		})
+	}
+}
+
+func TestFIFOQueuesPushNotRegistered(t *testing.T) {
+	queues := NewFIFOQueues()
+	assert.Equal(t, common.ErrInvalidAddress, queues.Push(0x04DE, 1))
+}
+
+func TestFIFOQueuesPop(t *testing.T) {
+	queues := NewFIFOQueues()
+	queues.Register(0x04DE)
+	assert.NoError(t, queues.Push(0x04DE, 1, 2))
+	value, ok := queues.Pop(0x04DE)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(1), value)
+	value, ok = queues.Pop(0x04DE)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(2), value)
+	_, ok = queues.Pop(0x04DE)
+	assert.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+)
+
+const (
+	// MaxFileRecordNumber is the highest record number the spec allows in a file.
+	MaxFileRecordNumber = 0x270F
+	// MaxFileRecordCount is the most records a file can hold.
+	MaxFileRecordCount = MaxFileRecordNumber + 1
+
+	// The response PDU is limited to 253 bytes, 2 of which are used by the function code and the response data length
+	maxFileRecordResponseBytes = 253 - 2
+)
+
+// FileStore is the storage behind the Read File Record and Write File Record functions. A file is a sequence of 16 bit
+// records addressed by file number and record number. Implementations should return ErrIllegalDataAddress for files or
+// records that don't exist so the client gets the right exception.
+type FileStore interface {
+	// ReadRecords reads count records starting at recordNumber from a file.
+	ReadRecords(fileNumber, recordNumber, count uint16) ([]uint16, error)
+	// WriteRecords writes values to a file starting at recordNumber.
+	WriteRecords(fileNumber, recordNumber uint16, values []uint16) error
+}
+
+// MemoryFileStore is a thread safe FileStore that keeps its files in memory.
+type MemoryFileStore struct {
+	mu    sync.RWMutex
+	files map[uint16][]uint16
+}
+
+// NewMemoryFileStore creates a new MemoryFileStore with no files.
+func NewMemoryFileStore() *MemoryFileStore {
+	return &MemoryFileStore{
+		files: make(map[uint16][]uint16),
+	}
+}
+
+// CreateFile creates a file of recordCount records set to 0, replacing any existing file with the same number.
+// File number 0 is not allowed by the spec.
+func (s *MemoryFileStore) CreateFile(fileNumber uint16, recordCount int) error {
+	if fileNumber == 0 {
+		return common.ErrInvalidAddress
+	}
+	if recordCount < 0 || recordCount > MaxFileRecordCount {
+		return common.ErrInvalidCount
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileNumber] = make([]uint16, recordCount)
+	return nil
+}
+
+// DeleteFile removes a file.
+func (s *MemoryFileStore) DeleteFile(fileNumber uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, fileNumber)
+}
+
+func (s *MemoryFileStore) ReadRecords(fileNumber, recordNumber, count uint16) ([]uint16, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[fileNumber]
+	if !ok || int(recordNumber)+int(count) > len(file) {
+		return nil, common.ErrIllegalDataAddress
+	}
+	return append([]uint16{}, file[recordNumber:recordNumber+count]...), nil
+}
+
+func (s *MemoryFileStore) WriteRecords(fileNumber, recordNumber uint16, values []uint16) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[fileNumber]
+	if !ok || int(recordNumber)+len(values) > len(file) {
+		return common.ErrIllegalDataAddress
+	}
+	copy(file[recordNumber:], values)
+	return nil
+}
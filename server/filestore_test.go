@@ -0,0 +1,101 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryFileStoreCreateFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileNumber    uint16
+		recordCount   int
+		expectedError error
+	}{
+		{
+			name:        "Valid",
+			fileNumber:  1,
+			recordCount: 10,
+		},
+		{
+			name:        "Valid_MaxRecords",
+			fileNumber:  1,
+			recordCount: MaxFileRecordCount,
+		},
+		{
+			name:          "FileZero",
+			fileNumber:    0,
+			recordCount:   10,
+			expectedError: common.ErrInvalidAddress,
+		},
+		{
+			name:          "TooManyRecords",
+			fileNumber:    1,
+			recordCount:   MaxFileRecordCount + 1,
+			expectedError: common.ErrInvalidCount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryFileStore()
+			err := store.CreateFile(tt.fileNumber, tt.recordCount)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestMemoryFileStoreReadWrite(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileNumber    uint16
+		recordNumber  uint16
+		values        []uint16
+		expectedError error
+	}{
+		{
+			name:         "Valid",
+			fileNumber:   4,
+			recordNumber: 7,
+			values:       []uint16{0x06AF, 0x04BE, 0x100D},
+		},
+		{
+			name:         "Valid_LastRecord",
+			fileNumber:   4,
+			recordNumber: 9,
+			values:       []uint16{0x06AF},
+		},
+		{
+			name:          "PastEndOfFile",
+			fileNumber:    4,
+			recordNumber:  8,
+			values:        []uint16{0x06AF, 0x04BE, 0x100D},
+			expectedError: common.ErrIllegalDataAddress,
+		},
+		{
+			name:          "MissingFile",
+			fileNumber:    5,
+			recordNumber:  0,
+			values:        []uint16{0x06AF},
+			expectedError: common.ErrIllegalDataAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryFileStore()
+			assert.NoError(t, store.CreateFile(4, 10))
+			err := store.WriteRecords(tt.fileNumber, tt.recordNumber, tt.values)
+			assert.Equal(t, tt.expectedError, err)
+			values, err := store.ReadRecords(tt.fileNumber, tt.recordNumber, uint16(len(tt.values)))
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.values, values)
+		})
+	}
+}
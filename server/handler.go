@@ -49,6 +49,18 @@ type RequestHandler interface {
 	WriteMultipleCoils(request data.ModbusWriteArrayRequest[[]bool]) (response *data.WriteMultipleCoilsResponse, err error)
 	// WriteMultipleRegisters writes multiple holding registers in this device.
 	WriteMultipleRegisters(request data.ModbusWriteArrayRequest[[]uint16]) (response *data.WriteMultipleRegistersResponse, err error)
+	// MaskWriteRegister modifies a single holding register in this device using a combination of an AND mask and an OR mask.
+	MaskWriteRegister(request data.ModbusMaskWriteRequest) (response *data.MaskWriteRegisterResponse, err error)
+	// ReadWriteMultipleRegisters writes multiple holding registers and then reads multiple holding registers in this device as a single transaction.
+	ReadWriteMultipleRegisters(request data.ModbusReadWriteArrayRequest) (response data.ModbusReadResponse[[]uint16], err error)
+	// ReadDeviceIdentification reads the identification objects of this device.
+	ReadDeviceIdentification(request data.ModbusReadDeviceIdentificationRequest) (response *data.ReadDeviceIdentificationResponse, err error)
+	// ReadFIFOQueue reads the contents of a FIFO queue of holding registers in this device.
+	ReadFIFOQueue(request data.ModbusReadFIFOQueueRequest) (response *data.ReadFIFOQueueResponse, err error)
+	// ReadFileRecord reads groups of records from the files in this device.
+	ReadFileRecord(request data.ModbusReadFileRecordRequest) (response *data.ReadFileRecordResponse, err error)
+	// WriteFileRecord writes groups of records to the files in this device.
+	WriteFileRecord(request data.ModbusWriteFileRecordRequest) (response *data.WriteFileRecordResponse, err error)
 }
 
 // PersistableRequestHandler is the interface that wraps the basic Modbus functions and provides methods to load and save server data.
@@ -69,6 +81,12 @@ type DefaultHandler struct {
 	DiscreteInputs   []bool
 	HoldingRegisters []uint16
 	InputRegisters   []uint16
+	// DeviceIdentification holds the objects returned by the Read Device Identification function.
+	DeviceIdentification *DeviceIdentification
+	// FIFOQueues holds the queues returned by the Read FIFO Queue function, no queues are registered by default.
+	FIFOQueues *FIFOQueues
+	// Files holds the files used by the Read File Record and Write File Record functions, it is an empty MemoryFileStore by default.
+	Files FileStore
 }
 
 // NewDefaultHandler creates a new DefaultHandler with the specified register counts. This is a PersistableRequestHandler, which means there is some internal locking
@@ -85,11 +103,14 @@ func NewDefaultHandler(logger *zap.Logger, coilCount, discreteInputCount, holdin
 		inputRegisterCount = DefaultInputRegisterCount
 	}
 	return &DefaultHandler{
-		logger:           logger,
-		Coils:            make([]bool, coilCount),
-		DiscreteInputs:   make([]bool, discreteInputCount),
-		HoldingRegisters: make([]uint16, holdingRegisterCount),
-		InputRegisters:   make([]uint16, inputRegisterCount),
+		logger:               logger,
+		Coils:                make([]bool, coilCount),
+		DiscreteInputs:       make([]bool, discreteInputCount),
+		HoldingRegisters:     make([]uint16, holdingRegisterCount),
+		InputRegisters:       make([]uint16, inputRegisterCount),
+		DeviceIdentification: NewDeviceIdentification(DefaultVendorName, DefaultProductCode, DefaultMajorMinorRevision),
+		FIFOQueues:           NewFIFOQueues(),
+		Files:                NewMemoryFileStore(),
 	}
 }
 
@@ -122,16 +143,49 @@ func (h *DefaultHandler) Handle(adu transport.ApplicationDataUnit) (*transport.P
 	case data.WriteMultipleRegisters:
 		// Write Multiple Registers
 		result, err = h.WriteMultipleRegisters(adu.PDU().Operation().(data.ModbusWriteArrayRequest[[]uint16]))
+	case data.MaskWriteRegister:
+		// Mask Write Register
+		result, err = h.MaskWriteRegister(adu.PDU().Operation().(data.ModbusMaskWriteRequest))
+	case data.ReadWriteMultipleRegisters:
+		// Read/Write Multiple Registers
+		result, err = h.ReadWriteMultipleRegisters(adu.PDU().Operation().(data.ModbusReadWriteArrayRequest))
+	case data.ReadFileRecord:
+		// Read File Record
+		result, err = h.ReadFileRecord(adu.PDU().Operation().(data.ModbusReadFileRecordRequest))
+	case data.WriteFileRecord:
+		// Write File Record
+		result, err = h.WriteFileRecord(adu.PDU().Operation().(data.ModbusWriteFileRecordRequest))
+	case data.ReadFIFOQueue:
+		// Read FIFO Queue
+		result, err = h.ReadFIFOQueue(adu.PDU().Operation().(data.ModbusReadFIFOQueueRequest))
+	case data.EncapsulatedInterfaceTransport:
+		// Read Device Identification
+		result, err = h.ReadDeviceIdentification(adu.PDU().Operation().(data.ModbusReadDeviceIdentificationRequest))
 	default:
-		h.logger.Debug("Received packet with unknown function code", zap.Any("packet", adu))
-		result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.IllegalFunction)
+		custom, ok := data.LookupCustomFunction(adu.PDU().FunctionCode())
+		if !ok || custom.Handler == nil {
+			h.logger.Debug("Received packet with unknown function code", zap.Any("packet", adu))
+			result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.IllegalFunction)
+			break
+		}
+		result, err = custom.Handler(adu.PDU().Operation().(data.ModbusCustomOperation))
+		if err == nil && result == nil {
+			// There's nothing to send back, so the request is treated as having failed
+			err = common.ErrServerDeviceFailure
+		}
 	}
 	switch err {
 	case nil:
 		break
+	case common.ErrIllegalFunction:
+		h.logger.Error("Failed to handle request", zap.Error(err))
+		result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.IllegalFunction)
 	case common.ErrIllegalDataAddress:
 		h.logger.Error("Failed to handle request", zap.Error(err))
 		result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.IllegalDataAddress)
+	case common.ErrIllegalDataValue:
+		h.logger.Error("Failed to handle request", zap.Error(err))
+		result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.IllegalDataValue)
 	default:
 		h.logger.Error("Failed to handle request", zap.Error(err))
 		result = data.NewModbusOperationException(adu.PDU().FunctionCode(), data.ServerDeviceFailure)
@@ -246,6 +300,106 @@ func (h *DefaultHandler) WriteMultipleRegisters(operation data.ModbusWriteArrayR
 	return data.NewWriteMultipleRegistersResponse(operation.Offset(), uint16(len(operation.Values()))), nil
 }
 
+func (h *DefaultHandler) MaskWriteRegister(operation data.ModbusMaskWriteRequest) (response *data.MaskWriteRegisterResponse, err error) {
+	// The read-modify-write has to be atomic, so we take the write lock
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logger.Debug("MaskWriteRegister", zap.Uint16("Offset", operation.Offset()), zap.Uint16("AndMask", operation.AndMask()), zap.Uint16("OrMask", operation.OrMask()))
+	if int(operation.Offset()) >= len(h.HoldingRegisters) {
+		return nil, common.ErrIllegalDataAddress
+	}
+	current := h.HoldingRegisters[operation.Offset()]
+	h.HoldingRegisters[operation.Offset()] = (current & operation.AndMask()) | (operation.OrMask() &^ operation.AndMask())
+	return data.NewMaskWriteRegisterResponse(operation.Offset(), operation.AndMask(), operation.OrMask()), nil
+}
+
+func (h *DefaultHandler) ReadWriteMultipleRegisters(operation data.ModbusReadWriteArrayRequest) (response data.ModbusReadResponse[[]uint16], err error) {
+	// The write and the read have to happen as one transaction, so we take the write lock for both
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logger.Debug("ReadWriteMultipleRegisters", zap.Uint16("ReadOffset", operation.ReadOffset()), zap.Int("ReadCount", operation.Count()), zap.Uint16("WriteOffset", operation.WriteOffset()), zap.Uint16s("Values", operation.Values()))
+	// A frame holds at most 125 registers read and 121 written
+	if operation.Count() < 1 || operation.Count() > 0x7D || len(operation.Values()) < 1 || len(operation.Values()) > 0x79 {
+		return nil, common.ErrIllegalDataValue
+	}
+	// The end is checked before getRange, which wraps past the last address
+	if int(operation.WriteOffset())+len(operation.Values()) > len(h.HoldingRegisters) {
+		return nil, common.ErrIllegalDataAddress
+	}
+	if int(operation.ReadOffset())+operation.Count() > len(h.HoldingRegisters) {
+		return nil, common.ErrIllegalDataAddress
+	}
+	writeStart, _ := getRange(operation.WriteOffset(), len(operation.Values()))
+	readStart, readEnd := getRange(operation.ReadOffset(), operation.Count())
+	for i, v := range operation.Values() {
+		h.HoldingRegisters[writeStart+uint16(i)] = v
+	}
+	results := make([]uint16, readEnd-readStart)
+	copy(results, h.HoldingRegisters[readStart:readEnd])
+	return data.NewReadWriteMultipleRegistersResponse(results), nil
+}
+
+func (h *DefaultHandler) ReadDeviceIdentification(operation data.ModbusReadDeviceIdentificationRequest) (response *data.ReadDeviceIdentificationResponse, err error) {
+	h.logger.Debug("ReadDeviceIdentification", zap.Uint8("DeviceIDCode", uint8(operation.DeviceIDCode())), zap.Uint8("ObjectID", uint8(operation.ObjectID())))
+	if h.DeviceIdentification == nil {
+		return nil, common.ErrIllegalFunction
+	}
+	return h.DeviceIdentification.Read(operation.DeviceIDCode(), operation.ObjectID())
+}
+
+func (h *DefaultHandler) ReadFIFOQueue(operation data.ModbusReadFIFOQueueRequest) (response *data.ReadFIFOQueueResponse, err error) {
+	h.logger.Debug("ReadFIFOQueue", zap.Uint16("Pointer", operation.Pointer()))
+	if h.FIFOQueues == nil {
+		return nil, common.ErrIllegalDataAddress
+	}
+	return h.FIFOQueues.Read(operation.Pointer())
+}
+
+func (h *DefaultHandler) ReadFileRecord(operation data.ModbusReadFileRecordRequest) (response *data.ReadFileRecordResponse, err error) {
+	h.logger.Debug("ReadFileRecord", zap.Int("SubRequests", len(operation.SubRequests())))
+	if h.Files == nil {
+		return nil, common.ErrIllegalFunction
+	}
+	// Each sub-response is a length byte, a reference type byte and the records, and they all have to fit in one PDU
+	size := 0
+	for _, s := range operation.SubRequests() {
+		if s.FileNumber == 0 || s.RecordNumber > MaxFileRecordNumber {
+			return nil, common.ErrIllegalDataAddress
+		}
+		size += 2 + 2*int(s.RecordLength)
+	}
+	if size > maxFileRecordResponseBytes {
+		return nil, common.ErrIllegalDataValue
+	}
+	records := make([][]uint16, 0, len(operation.SubRequests()))
+	for _, s := range operation.SubRequests() {
+		values, err := h.Files.ReadRecords(s.FileNumber, s.RecordNumber, s.RecordLength)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, values)
+	}
+	return data.NewReadFileRecordResponse(records), nil
+}
+
+func (h *DefaultHandler) WriteFileRecord(operation data.ModbusWriteFileRecordRequest) (response *data.WriteFileRecordResponse, err error) {
+	h.logger.Debug("WriteFileRecord", zap.Int("SubRequests", len(operation.FileRecords())))
+	if h.Files == nil {
+		return nil, common.ErrIllegalFunction
+	}
+	for _, r := range operation.FileRecords() {
+		if r.FileNumber == 0 || r.RecordNumber > MaxFileRecordNumber {
+			return nil, common.ErrIllegalDataAddress
+		}
+	}
+	for _, r := range operation.FileRecords() {
+		if err := h.Files.WriteRecords(r.FileNumber, r.RecordNumber, r.Values); err != nil {
+			return nil, err
+		}
+	}
+	return data.NewWriteFileRecordResponse(operation.FileRecords()), nil
+}
+
 func (h *DefaultHandler) Load(dataPath string) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -90,3 +90,75 @@ func TestHandlerReadCoils(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerMaskWriteRegister(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tests := []struct {
+		name          string
+		offset        uint16
+		initial       uint16
+		andMask       uint16
+		orMask        uint16
+		expected      uint16
+		expectedError error
+	}{
+		{"Valid", 4, 0x0012, 0x00F2, 0x0025, 0x0017, nil},
+		{"SetBit", 4, 0x0000, 0xFFFF ^ 0x0008, 0x0008, 0x0008, nil},
+		{"ClearBit", 4, 0xFFFF, 0xFFFF ^ 0x0008, 0x0000, 0xFFF7, nil},
+		{"InvalidOffset", 10, 0, 0, 0, 0, common.ErrIllegalDataAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewDefaultHandler(logger, 10, 10, 10, 10)
+			if tt.expectedError == nil {
+				handler.(*DefaultHandler).HoldingRegisters[tt.offset] = tt.initial
+			}
+			req := data.NewMaskWriteRegisterRequest(tt.offset, tt.andMask, tt.orMask)
+			_, err := handler.MaskWriteRegister(req)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, handler.(*DefaultHandler).HoldingRegisters[tt.offset])
+		})
+	}
+}
+
+func TestHandlerReadWriteMultipleRegisters(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tests := []struct {
+		name          string
+		registerCount uint16
+		readOffset    uint16
+		readQuantity  uint16
+		writeOffset   uint16
+		values        []uint16
+		expectedError error
+	}{
+		{"Valid", 10, 0, 10, 2, []uint16{1, 2}, nil},
+		{"InvalidReadOffset", 10, 9, 2, 0, []uint16{1}, common.ErrIllegalDataAddress},
+		{"InvalidWriteOffset", 10, 0, 1, 9, []uint16{1, 2}, common.ErrIllegalDataAddress},
+		{"WriteOffsetWraps", 0xFFFF, 0, 1, 0xFFFF, []uint16{1, 2}, common.ErrIllegalDataAddress},
+		{"ReadOffsetWraps", 0xFFFF, 0xFFFF, 2, 0, []uint16{1}, common.ErrIllegalDataAddress},
+		{"NoReadQuantity", 10, 0, 0, 0, []uint16{1}, common.ErrIllegalDataValue},
+		{"ReadQuantityTooLarge", 200, 0, 126, 0, []uint16{1}, common.ErrIllegalDataValue},
+		{"NoValues", 10, 0, 1, 0, []uint16{}, common.ErrIllegalDataValue},
+		{"TooManyValues", 200, 0, 1, 0, make([]uint16, 122), common.ErrIllegalDataValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewDefaultHandler(logger, 10, 10, tt.registerCount, 10)
+			req := data.NewReadWriteMultipleRegistersRequest(tt.readOffset, tt.readQuantity, tt.writeOffset, tt.values)
+			resp, err := handler.ReadWriteMultipleRegisters(req)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []uint16{0, 0, 1, 2, 0, 0, 0, 0, 0, 0}, resp.Values())
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/server"
 	settings "github.com/rinzlerlabs/gomodbus/settings/network"
 	"github.com/rinzlerlabs/gomodbus/transport"
@@ -15,7 +16,36 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewModbusServer(logger *zap.Logger, uri string) (server.ModbusServer, error) {
+// UnknownUnitBehavior is how a server answers requests to a unit ID that has no handler of its own.
+type UnknownUnitBehavior int
+
+const (
+	// UnknownUnitDefaultHandler passes the requests to the handler the server was created with, so every unit ID is
+	// answered. It is the default.
+	UnknownUnitDefaultHandler UnknownUnitBehavior = iota
+	// UnknownUnitNoResponse drops the requests, leaving the client to time out like a device that isn't there.
+	UnknownUnitNoResponse
+	// UnknownUnitGatewayPathUnavailable answers the requests with a Gateway Path Unavailable exception, like a gateway
+	// with no route to the unit.
+	UnknownUnitGatewayPathUnavailable
+)
+
+// maxUnitID is the highest unit ID that fits in the MBAP header
+const maxUnitID = 0xFF
+
+type ModbusNetworkServer interface {
+	server.ModbusServer
+	// Handler returns the handler the server was created with.
+	Handler() server.RequestHandler
+	// SetUnitHandler answers requests to unitID with handler, so one listener can host several devices.
+	SetUnitHandler(unitID uint16, handler server.RequestHandler) error
+	// RemoveUnitHandler stops answering requests to unitID with its own handler, they are treated like any unknown unit.
+	RemoveUnitHandler(unitID uint16)
+	// SetUnknownUnitBehavior sets how requests to unit IDs without a handler of their own are answered.
+	SetUnknownUnitBehavior(behavior UnknownUnitBehavior)
+}
+
+func NewModbusServer(logger *zap.Logger, uri string) (ModbusNetworkServer, error) {
 	settings, err := settings.NewServerSettingsFromURI(uri)
 	if err != nil {
 		return nil, err
@@ -23,12 +53,12 @@ func NewModbusServer(logger *zap.Logger, uri string) (server.ModbusServer, error
 	return NewModbusServerFromSettings(logger, settings)
 }
 
-func NewModbusServerFromSettings(logger *zap.Logger, serverSettings *settings.ServerSettings) (server.ModbusServer, error) {
+func NewModbusServerFromSettings(logger *zap.Logger, serverSettings *settings.ServerSettings) (ModbusNetworkServer, error) {
 	handler := server.NewDefaultHandler(logger, server.DefaultCoilCount, server.DefaultDiscreteInputCount, server.DefaultHoldingRegisterCount, server.DefaultInputRegisterCount)
 	return NewModbusServerWithHandler(logger, serverSettings, handler)
 }
 
-func NewModbusServerWithHandler(logger *zap.Logger, serverSettings *settings.ServerSettings, handler server.RequestHandler) (server.ModbusServer, error) {
+func NewModbusServerWithHandler(logger *zap.Logger, serverSettings *settings.ServerSettings, handler server.RequestHandler) (ModbusNetworkServer, error) {
 	if handler == nil {
 		return nil, common.ErrHandlerRequired
 	}
@@ -37,6 +67,7 @@ func NewModbusServerWithHandler(logger *zap.Logger, serverSettings *settings.Ser
 	return &modbusServer{
 		logger:       logger,
 		handler:      handler,
+		units:        make(map[uint16]server.RequestHandler),
 		cancelCtx:    ctx,
 		cancel:       cancel,
 		stats:        server.NewServerStats(),
@@ -46,7 +77,11 @@ func NewModbusServerWithHandler(logger *zap.Logger, serverSettings *settings.Ser
 }
 
 type modbusServer struct {
-	handler      server.RequestHandler
+	handler server.RequestHandler
+	// units is the handler of each unit ID that has its own, unknownUnit is how the others are answered
+	units        map[uint16]server.RequestHandler
+	unknownUnit  UnknownUnitBehavior
+	unitsMu      sync.RWMutex
 	cancelCtx    context.Context
 	cancel       context.CancelFunc
 	logger       *zap.Logger
@@ -120,6 +155,65 @@ func (s *modbusServer) Stats() *server.ServerStats {
 	return s.stats
 }
 
+func (s *modbusServer) Handler() server.RequestHandler {
+	return s.handler
+}
+
+func (s *modbusServer) SetUnitHandler(unitID uint16, handler server.RequestHandler) error {
+	if handler == nil {
+		return common.ErrHandlerRequired
+	}
+	if unitID > maxUnitID {
+		return common.ErrInvalidAddress
+	}
+	s.unitsMu.Lock()
+	defer s.unitsMu.Unlock()
+	s.units[unitID] = handler
+	return nil
+}
+
+func (s *modbusServer) RemoveUnitHandler(unitID uint16) {
+	s.unitsMu.Lock()
+	defer s.unitsMu.Unlock()
+	delete(s.units, unitID)
+}
+
+func (s *modbusServer) SetUnknownUnitBehavior(behavior UnknownUnitBehavior) {
+	s.unitsMu.Lock()
+	defer s.unitsMu.Unlock()
+	s.unknownUnit = behavior
+}
+
+// route returns the handler for a request to unitID, or the behavior for a unit without one
+func (s *modbusServer) route(unitID uint16) (server.RequestHandler, UnknownUnitBehavior) {
+	s.unitsMu.RLock()
+	defer s.unitsMu.RUnlock()
+	if handler, ok := s.units[unitID]; ok {
+		return handler, UnknownUnitDefaultHandler
+	}
+	if s.unknownUnit == UnknownUnitDefaultHandler {
+		return s.handler, UnknownUnitDefaultHandler
+	}
+	return nil, s.unknownUnit
+}
+
+// handle answers a request with the handler of its unit ID, a nil response means no answer is sent
+func (s *modbusServer) handle(op transport.ApplicationDataUnit) (*transport.ProtocolDataUnit, bool, error) {
+	unitID := uint16(op.Header().(transport.NetworkHeader).UnitID())
+	handler, behavior := s.route(unitID)
+	switch {
+	case handler != nil:
+		resp, err := handler.Handle(op)
+		return resp, true, err
+	case behavior == UnknownUnitGatewayPathUnavailable:
+		exception := data.NewModbusOperationException(op.PDU().FunctionCode(), data.GatewayPathUnavailable)
+		return transport.NewProtocolDataUnit(exception), true, nil
+	default:
+		s.logger.Debug("Dropping request to unknown unit", zap.Uint16("unitID", unitID))
+		return nil, false, nil
+	}
+}
+
 func (s *modbusServer) run() {
 	s.logger.Info("Modbus TCP server started")
 	for {
@@ -165,16 +259,23 @@ func (s *modbusServer) handleClient(conn net.Conn) {
 		} else if errors.Is(err, context.Canceled) {
 			s.logger.Debug("Server context canceled, cleaning up transport and client", zap.String("remote", conn.RemoteAddr().String()), zap.Error(err))
 			return
+		} else if errors.Is(err, common.ErrInvalidHeader) {
+			s.logger.Warn("Lost track of the frames from the client, closing the connection", zap.String("remote", conn.RemoteAddr().String()), zap.Error(err))
+			s.stats.AddError(err)
+			return
 		} else if err != nil {
 			s.logger.Error("Failed to accept request", zap.Error(err))
 			continue
 		}
 		s.stats.AddRequest(op)
-		resp, err := s.handler.Handle(op)
+		resp, answer, err := s.handle(op)
 		if err != nil {
 			s.stats.AddError(err)
 			s.logger.Error("Failed to handle request", zap.Error(err))
 		}
+		if !answer {
+			continue
+		}
 		if err := t.WriteResponseFrame(op.Header(), resp); err != nil {
 			s.stats.AddError(err)
 			s.logger.Error("Failed to write response", zap.Error(err))
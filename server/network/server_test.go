@@ -20,7 +20,7 @@ import (
 	"go.uber.org/zap/zaptest"
 )
 
-func newModbusServerWithHandler(logger *zap.Logger, listener net.Listener, handler server.RequestHandler) (server.ModbusServer, error) {
+func newModbusServerWithHandler(logger *zap.Logger, listener net.Listener, handler server.RequestHandler) (ModbusNetworkServer, error) {
 	if handler == nil {
 		return nil, common.ErrHandlerRequired
 	}
@@ -33,6 +33,7 @@ func newModbusServerWithHandler(logger *zap.Logger, listener net.Listener, handl
 	return &modbusServer{
 		logger:    logger,
 		handler:   handler,
+		units:     make(map[uint16]server.RequestHandler),
 		cancelCtx: ctx,
 		cancel:    cancel,
 		listener:  listener,
@@ -520,6 +521,94 @@ func TestWriteMultipleRegisters(t *testing.T) {
 	}
 }
 
+func TestUnitHandlers(t *testing.T) {
+	// Each handler answers with its own number in the first holding register
+	newHandler := func(logger *zap.Logger, value uint16) server.RequestHandler {
+		handler := server.NewDefaultHandler(logger, 16, 16, 16, 16)
+		handler.(*server.DefaultHandler).HoldingRegisters[0] = value
+		return handler
+	}
+	tests := []struct {
+		name     string
+		behavior UnknownUnitBehavior
+		request  string
+		response string
+	}{
+		{
+			name:     "Unit handler",
+			request:  "000100000006020300000001",
+			response: "0001000000050203020002",
+		},
+		{
+			name:     "Unknown unit default handler",
+			request:  "000100000006050300000001",
+			response: "0001000000050503020001",
+		},
+		{
+			name:     "Unknown unit gateway path unavailable",
+			behavior: UnknownUnitGatewayPathUnavailable,
+			request:  "000100000006050300000001",
+			response: "00010000000305830A",
+		},
+		{
+			name:     "Unknown unit no response",
+			behavior: UnknownUnitNoResponse,
+			request:  "000100000006050300000001",
+		},
+		{
+			name:     "Unit handler with unknown units dropped",
+			behavior: UnknownUnitNoResponse,
+			request:  "000100000006030300000001",
+			response: "0001000000050303020003",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// The accept loop can outlive the test, so it can't log to t
+			logger := zap.NewNop()
+			listener := &testListener{
+				readData: [][]byte{[]byte(tt.request)},
+			}
+			s, err := newModbusServerWithHandler(logger, listener, newHandler(logger, 1))
+			assert.NoError(t, err)
+			assert.NoError(t, s.SetUnitHandler(2, newHandler(logger, 2)))
+			assert.NoError(t, s.SetUnitHandler(3, newHandler(logger, 3)))
+			s.SetUnknownUnitBehavior(tt.behavior)
+
+			s.Start()
+			if tt.response == "" {
+				// Give the server time to drop the request
+				time.Sleep(100 * time.Millisecond)
+			}
+			waitForWrite(listener, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, strings.ToUpper(hex.EncodeToString(listener.writeData)))
+		})
+	}
+}
+
+func TestSetUnitHandler(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	handler := server.NewDefaultHandler(logger, 16, 16, 16, 16)
+	s, err := NewModbusServerWithHandler(logger, &settings.ServerSettings{}, handler)
+	assert.NoError(t, err)
+	assert.Same(t, handler, s.Handler())
+	assert.ErrorIs(t, s.SetUnitHandler(0x100, handler), common.ErrInvalidAddress)
+	assert.ErrorIs(t, s.SetUnitHandler(1, nil), common.ErrHandlerRequired)
+
+	unit := server.NewDefaultHandler(logger, 16, 16, 16, 16)
+	assert.NoError(t, s.SetUnitHandler(0xFF, unit))
+	routed, _ := s.(*modbusServer).route(0xFF)
+	assert.Same(t, unit, routed)
+	s.RemoveUnitHandler(0xFF)
+	routed, _ = s.(*modbusServer).route(0xFF)
+	assert.Same(t, handler, routed)
+}
+
 func TestGenerateLrc(t *testing.T) {
 	data, err := hex.DecodeString("0401020000")
 	assert.NoError(t, err)
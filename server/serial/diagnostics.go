@@ -0,0 +1,180 @@
+package serial
+
+import (
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// DiagnosticCounters are the serial line counters reported by the Diagnostics function, like the spec they are
+// 16 bits wide and roll over.
+type DiagnosticCounters struct {
+	mu                         sync.Mutex
+	busMessageCount            uint16
+	busCommunicationErrorCount uint16
+	busExceptionErrorCount     uint16
+	serverMessageCount         uint16
+	serverNoResponseCount      uint16
+}
+
+// BusMessageCount is the number of valid frames read from the bus, including the ones for other addresses.
+func (c *DiagnosticCounters) BusMessageCount() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.busMessageCount
+}
+
+// BusCommunicationErrorCount is the number of frames that failed the checksum.
+func (c *DiagnosticCounters) BusCommunicationErrorCount() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.busCommunicationErrorCount
+}
+
+// BusExceptionErrorCount is the number of exception responses sent by this server.
+func (c *DiagnosticCounters) BusExceptionErrorCount() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.busExceptionErrorCount
+}
+
+// ServerMessageCount is the number of frames addressed to this server, including broadcasts.
+func (c *DiagnosticCounters) ServerMessageCount() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverMessageCount
+}
+
+// ServerNoResponseCount is the number of frames addressed to this server that were not answered.
+func (c *DiagnosticCounters) ServerNoResponseCount() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverNoResponseCount
+}
+
+func (c *DiagnosticCounters) addBusMessage() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busMessageCount++
+}
+
+func (c *DiagnosticCounters) addBusCommunicationError() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busCommunicationErrorCount++
+}
+
+func (c *DiagnosticCounters) addBusExceptionError() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busExceptionErrorCount++
+}
+
+func (c *DiagnosticCounters) addServerMessage() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serverMessageCount++
+}
+
+func (c *DiagnosticCounters) addServerNoResponse() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serverNoResponseCount++
+}
+
+// Clear resets all of the counters to zero.
+func (c *DiagnosticCounters) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busMessageCount = 0
+	c.busCommunicationErrorCount = 0
+	c.busExceptionErrorCount = 0
+	c.serverMessageCount = 0
+	c.serverNoResponseCount = 0
+}
+
+func (c *DiagnosticCounters) counter(subFunction data.DiagnosticSubFunction) uint16 {
+	switch subFunction {
+	case data.ReturnBusMessageCount:
+		return c.BusMessageCount()
+	case data.ReturnBusCommunicationErrorCount:
+		return c.BusCommunicationErrorCount()
+	case data.ReturnBusExceptionErrorCount:
+		return c.BusExceptionErrorCount()
+	case data.ReturnServerMessageCount:
+		return c.ServerMessageCount()
+	case data.ReturnServerNoResponseCount:
+		return c.ServerNoResponseCount()
+	default:
+		return 0
+	}
+}
+
+func (c *DiagnosticCounters) AsMap() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"BusMessageCount":            c.busMessageCount,
+		"BusCommunicationErrorCount": c.busCommunicationErrorCount,
+		"BusExceptionErrorCount":     c.busExceptionErrorCount,
+		"ServerMessageCount":         c.serverMessageCount,
+		"ServerNoResponseCount":      c.serverNoResponseCount,
+	}
+}
+
+// handleDiagnostics answers a Diagnostics request from the serial line state, a nil response means nothing is sent
+func (s *modbusSerialServer) handleDiagnostics(request data.ModbusDiagnosticsRequest) data.ModbusOperation {
+	subFunction := request.SubFunction()
+	if subFunction == data.ReturnQueryData {
+		return data.NewDiagnosticsResponse(subFunction, request.Data())
+	}
+	if len(request.Data()) != 2 {
+		return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+	}
+	value := uint16(request.Data()[0])<<8 | uint16(request.Data()[1])
+	switch subFunction {
+	case data.RestartCommunicationsOption:
+		if value != 0x0000 && value != 0xFF00 {
+			return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+		}
+		s.counters.Clear()
+		s.eventLog.clearEventCount()
+		if value == 0xFF00 {
+			s.eventLog.Clear()
+		}
+		s.eventLog.add(data.CommunicationRestartEvent)
+		// A restart takes the server out of listen only mode, but the request that did it isn't answered
+		if s.listenOnly.Swap(false) {
+			return nil
+		}
+		return data.NewDiagnosticsResponse(subFunction, request.Data())
+	case data.ForceListenOnlyMode:
+		if value != 0x0000 {
+			return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+		}
+		s.listenOnly.Store(true)
+		s.eventLog.add(data.ListenOnlyModeEvent)
+		return nil
+	case data.ReturnDiagnosticRegister:
+		// We don't have any diagnostic flags to report
+		if value != 0x0000 {
+			return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+		}
+		return data.NewDiagnosticsResponse(subFunction, []byte{0x00, 0x00})
+	case data.ClearCountersAndDiagnosticRegister:
+		if value != 0x0000 {
+			return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+		}
+		s.counters.Clear()
+		s.eventLog.clearEventCount()
+		return data.NewDiagnosticsResponse(subFunction, request.Data())
+	case data.ReturnBusMessageCount, data.ReturnBusCommunicationErrorCount, data.ReturnBusExceptionErrorCount, data.ReturnServerMessageCount, data.ReturnServerNoResponseCount:
+		if value != 0x0000 {
+			return data.NewModbusOperationException(data.Diagnostics, data.IllegalDataValue)
+		}
+		count := s.counters.counter(subFunction)
+		return data.NewDiagnosticsResponse(subFunction, []byte{byte(count >> 8), byte(count)})
+	default:
+		return data.NewModbusOperationException(data.Diagnostics, data.IllegalFunction)
+	}
+}
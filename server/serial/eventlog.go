@@ -0,0 +1,110 @@
+package serial
+
+import (
+	"sync"
+
+	"github.com/rinzlerlabs/gomodbus/data"
+)
+
+// The spec limits the comm event log to 64 events
+const maxCommEvents = 64
+
+// ExceptionStatusSource provides the 8 exception status bits returned by Read Exception Status, what each bit means
+// is up to the device.
+type ExceptionStatusSource interface {
+	ExceptionStatus() byte
+}
+
+// ExceptionStatusFunc adapts an ordinary function to an ExceptionStatusSource.
+type ExceptionStatusFunc func() byte
+
+func (f ExceptionStatusFunc) ExceptionStatus() byte {
+	return f()
+}
+
+// CommEventLog is the ring buffer of events returned by Get Comm Event Log along with the comm event counter.
+type CommEventLog struct {
+	mu         sync.Mutex
+	events     [maxCommEvents]byte
+	next       int
+	size       int
+	eventCount uint16
+}
+
+func (l *CommEventLog) add(event byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events[l.next] = event
+	l.next = (l.next + 1) % maxCommEvents
+	if l.size < maxCommEvents {
+		l.size++
+	}
+}
+
+func (l *CommEventLog) addReceiveEvent(flags byte) {
+	l.add(data.ReceiveEvent | flags)
+}
+
+func (l *CommEventLog) addSendEvent(flags byte) {
+	l.add(data.SendEvent | flags)
+}
+
+// incrementEventCount counts a successfully completed request
+func (l *CommEventLog) incrementEventCount() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eventCount++
+}
+
+// Events returns the logged events, the most recent event first.
+func (l *CommEventLog) Events() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := make([]byte, l.size)
+	for i := 0; i < l.size; i++ {
+		events[i] = l.events[(l.next-1-i+maxCommEvents)%maxCommEvents]
+	}
+	return events
+}
+
+// EventCount returns the number of requests that completed normally.
+func (l *CommEventLog) EventCount() uint16 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.eventCount
+}
+
+// Clear removes all events from the log.
+func (l *CommEventLog) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.next = 0
+	l.size = 0
+}
+
+func (l *CommEventLog) clearEventCount() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eventCount = 0
+}
+
+// sendEventFlags maps the exception code of a response to the bits of its send event
+func sendEventFlags(op data.ModbusOperation) byte {
+	exception, ok := op.(*data.ModbusOperationException)
+	if !ok {
+		return 0x00
+	}
+	switch exception.ExceptionCode {
+	case data.IllegalFunction, data.IllegalDataAddress, data.IllegalDataValue:
+		return data.SendEventReadException
+	case data.ServerDeviceFailure:
+		return data.SendEventServerAbortException
+	case data.Acknowledge, data.ServerDeviceBusy:
+		return data.SendEventServerBusyException
+	case 0x07:
+		// Negative Acknowledge, only used by program commands which we don't support
+		return data.SendEventServerProgramNAKException
+	default:
+		return 0x00
+	}
+}
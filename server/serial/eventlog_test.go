@@ -0,0 +1,21 @@
+package serial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommEventLogWraps(t *testing.T) {
+	log := &CommEventLog{}
+	for i := 0; i < maxCommEvents+3; i++ {
+		log.add(byte(i))
+	}
+	events := log.Events()
+	assert.Len(t, events, maxCommEvents)
+	assert.Equal(t, byte(maxCommEvents+2), events[0])
+	assert.Equal(t, byte(3), events[maxCommEvents-1])
+
+	log.Clear()
+	assert.Empty(t, log.Events())
+}
@@ -3,12 +3,16 @@ package rtu
 import (
 	"io"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/server"
 	"github.com/rinzlerlabs/gomodbus/server/serial"
 	settings "github.com/rinzlerlabs/gomodbus/settings/serial"
+	"github.com/rinzlerlabs/gomodbus/transport"
 	"github.com/rinzlerlabs/gomodbus/transport/serial/rtu"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -348,6 +352,149 @@ func TestWriteSingleCoil(t *testing.T) {
 	}
 }
 
+func TestBroadcast(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{
+			0x00, 0x05, 0x00, 0x0A, 0xFF, 0x00, 0xAD, 0xE9,
+			0x00, 0x06, 0x00, 0x0A, 0x12, 0x34, 0xA5, 0x6E,
+		},
+	}
+	handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+	s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+	assert.NoError(t, err)
+
+	s.Start()
+	assert.Eventually(t, func() bool { return s.DiagnosticCounters().ServerNoResponseCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	err = s.Close()
+	assert.NoError(t, err)
+	assert.True(t, handler.(*server.DefaultHandler).Coils[0x000A+1])
+	assert.Equal(t, uint16(0x1234), handler.(*server.DefaultHandler).HoldingRegisters[0x000A+1])
+	assert.Nil(t, port.writeData)
+	assert.Equal(t, uint16(2), s.DiagnosticCounters().ServerMessageCount())
+}
+
+func TestUnitHandlers(t *testing.T) {
+	// Each handler answers with its own unit address in the first holding register
+	newHandler := func(logger *zap.Logger, unit uint16) server.RequestHandler {
+		handler := server.NewDefaultHandler(logger, 16, 16, 16, 16)
+		handler.(*server.DefaultHandler).HoldingRegisters[0] = unit
+		handler.(*server.DefaultHandler).HoldingRegisters[1] = unit
+		return handler
+	}
+	tests := []struct {
+		name     string
+		setup    func(logger *zap.Logger, s serial.ModbusSerialServer)
+		request  []byte
+		response []byte
+	}{
+		{
+			name:     "Own address",
+			setup:    func(logger *zap.Logger, s serial.ModbusSerialServer) {},
+			request:  []byte{0x04, 0x03, 0x00, 0x00, 0x00, 0x01, 0x84, 0x5F},
+			response: []byte{0x04, 0x03, 0x02, 0x00, 0x04, 0x75, 0x87},
+		},
+		{
+			name: "Unit handler",
+			setup: func(logger *zap.Logger, s serial.ModbusSerialServer) {
+				assert.NoError(t, s.SetUnitHandler(0x05, newHandler(logger, 0x05)))
+			},
+			request:  []byte{0x05, 0x03, 0x00, 0x00, 0x00, 0x01, 0x85, 0x8E},
+			response: []byte{0x05, 0x03, 0x02, 0x00, 0x05, 0x89, 0x87},
+		},
+		{
+			name: "No handler",
+			setup: func(logger *zap.Logger, s serial.ModbusSerialServer) {
+				assert.NoError(t, s.SetUnitHandler(0x05, newHandler(logger, 0x05)))
+			},
+			request: []byte{0x06, 0x03, 0x00, 0x00, 0x00, 0x01, 0x85, 0xBD},
+		},
+		{
+			name: "Fallback handler",
+			setup: func(logger *zap.Logger, s serial.ModbusSerialServer) {
+				s.SetFallbackHandler(newHandler(logger, 0x06))
+			},
+			request:  []byte{0x06, 0x03, 0x00, 0x00, 0x00, 0x01, 0x85, 0xBD},
+			response: []byte{0x06, 0x03, 0x02, 0x00, 0x06, 0x8D, 0x86},
+		},
+		{
+			name: "Removed handler",
+			setup: func(logger *zap.Logger, s serial.ModbusSerialServer) {
+				s.RemoveUnitHandler(0x04)
+			},
+			request: []byte{0x04, 0x03, 0x00, 0x00, 0x00, 0x01, 0x84, 0x5F},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.request),
+			}
+			s, err := newModbusServerWithHandler(logger, port, 0x04, newHandler(logger, 0x04))
+			assert.NoError(t, err)
+			tt.setup(logger, s)
+
+			s.Start()
+			if tt.response == nil {
+				// Give the server time to skip the request
+				time.Sleep(300 * time.Millisecond)
+			}
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+// countingHandler counts the requests passed to a handler
+type countingHandler struct {
+	server.RequestHandler
+	count atomic.Int32
+}
+
+func (h *countingHandler) Handle(adu transport.ApplicationDataUnit) (*transport.ProtocolDataUnit, error) {
+	h.count.Add(1)
+	return h.RequestHandler.Handle(adu)
+}
+
+func TestUnitHandlerBroadcast(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		// A broadcast Write Single Coil, then a broadcast Read Coils that is ignored
+		readData: []byte{0x00, 0x05, 0x00, 0x0A, 0xFF, 0x00, 0xAD, 0xE9, 0x00, 0x01, 0x00, 0x0A, 0x00, 0x01, 0xDC, 0x19},
+	}
+	own := &countingHandler{RequestHandler: server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)}
+	unit := &countingHandler{RequestHandler: server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)}
+	fallback := &countingHandler{RequestHandler: server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)}
+	s, err := newModbusServerWithHandler(logger, port, 0x04, own)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SetUnitHandler(0x05, unit))
+	assert.NoError(t, s.SetUnitHandler(0x06, unit))
+	s.SetFallbackHandler(fallback)
+	assert.ErrorIs(t, s.SetUnitHandler(0x00, unit), common.ErrInvalidAddress)
+	assert.ErrorIs(t, s.SetUnitHandler(248, unit), common.ErrInvalidAddress)
+	assert.ErrorIs(t, s.SetUnitHandler(0x06, nil), common.ErrHandlerRequired)
+
+	s.Start()
+	assert.Eventually(t, func() bool { return s.DiagnosticCounters().ServerNoResponseCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	err = s.Close()
+	assert.NoError(t, err)
+	// Every device carries out a broadcast write once, even when it answers several addresses
+	for _, handler := range []*countingHandler{own, unit, fallback} {
+		assert.True(t, handler.RequestHandler.(*server.DefaultHandler).Coils[0x000A+1])
+		assert.Equal(t, int32(1), handler.count.Load())
+	}
+	assert.Same(t, own, s.Handler())
+	assert.Nil(t, port.writeData)
+}
+
 func TestWriteSingleRegister(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -497,3 +644,471 @@ func TestWriteMultipleRegisters(t *testing.T) {
 		})
 	}
 }
+
+func TestReadWriteMultipleRegisters(t *testing.T) {
+	tests := []struct {
+		name              string
+		request           []byte
+		response          []byte
+		expectedRegisters []uint16
+	}{
+		{
+			name:              "Valid",
+			request:           []byte{0x04, 0x17, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x04, 0x00, 0x04, 0x00, 0x02, 0xC2, 0x43},
+			response:          []byte{0x04, 0x17, 0x04, 0x00, 0x04, 0x00, 0x02, 0x6C, 0x27},
+			expectedRegisters: []uint16{0x0004, 0x0002},
+		},
+		{
+			name:    "InvalidRequest_InvalidChecksum",
+			request: []byte{0x04, 0x17, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x04, 0x00, 0x04, 0x00, 0x02, 0xC2, 0x44},
+		},
+		{
+			name:    "InvalidRequest_NotOurAddress",
+			request: []byte{0x05, 0x17, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x04, 0x00, 0x04, 0x00, 0x02, 0x03, 0x43},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.request),
+			}
+			handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+			s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			if tt.expectedRegisters != nil {
+				assert.Equal(t, tt.expectedRegisters, handler.(*server.DefaultHandler).HoldingRegisters[0:2])
+			}
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  []byte
+		response []byte
+	}{
+		{
+			name:     "ReturnQueryData",
+			request:  []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+			response: []byte{0x04, 0x08, 0x00, 0x00, 0xA5, 0x37, 0xDA, 0xD8},
+		},
+		{
+			name:     "ReturnServerMessageCount",
+			request:  []byte{0x04, 0x08, 0x00, 0x0E, 0x00, 0x00, 0x81, 0x9D},
+			response: []byte{0x04, 0x08, 0x00, 0x0E, 0x00, 0x01, 0x40, 0x5D},
+		},
+		{
+			name: "ReturnBusMessageCount",
+			request: []byte{
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+				0x04, 0x08, 0x00, 0x0B, 0x00, 0x00, 0x91, 0x9C,
+			},
+			response: []byte{0x04, 0x08, 0x00, 0x0B, 0x00, 0x02, 0x10, 0x5D},
+		},
+		{
+			name: "ReturnBusCommunicationErrorCount",
+			request: []byte{
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9E,
+				0x04, 0x08, 0x00, 0x0C, 0x00, 0x00, 0x20, 0x5D,
+			},
+			response: []byte{0x04, 0x08, 0x00, 0x0C, 0x00, 0x01, 0xE1, 0x9D},
+		},
+		{
+			name: "ReturnBusExceptionErrorCount",
+			request: []byte{
+				0x04, 0x08, 0x00, 0x20, 0x00, 0x00, 0xE1, 0x94,
+				0x04, 0x08, 0x00, 0x0D, 0x00, 0x00, 0x71, 0x9D,
+			},
+			response: []byte{0x04, 0x08, 0x00, 0x0D, 0x00, 0x01, 0xB0, 0x5D},
+		},
+		{
+			name:     "UnsupportedSubFunction",
+			request:  []byte{0x04, 0x08, 0x00, 0x20, 0x00, 0x00, 0xE1, 0x94},
+			response: []byte{0x04, 0x88, 0x01, 0x97, 0xC1},
+		},
+		{
+			name: "RestartCommunicationsLeavesListenOnlyMode",
+			request: []byte{
+				0x04, 0x08, 0x00, 0x04, 0x00, 0x00, 0xA1, 0x9F,
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+				0x04, 0x08, 0x00, 0x01, 0x00, 0x00, 0xB1, 0x9E,
+				0x04, 0x08, 0x00, 0x0E, 0x00, 0x00, 0x81, 0x9D,
+			},
+			response: []byte{0x04, 0x08, 0x00, 0x0E, 0x00, 0x01, 0x40, 0x5D},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.request),
+			}
+			s, err := newModbusServerWithHandler(logger, port, 0x04, server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024))
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+			assert.False(t, s.IsListenOnly())
+		})
+	}
+}
+
+func TestBusMessageCountIncludesOtherAddresses(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{
+			0x05, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFC, 0x4E,
+			0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+		},
+	}
+	s, err := newModbusServerWithHandler(logger, port, 0x04, server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024))
+	assert.NoError(t, err)
+
+	s.Start()
+	waitForWrite(port, 6)
+
+	err = s.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x04, 0x01, 0x01, 0x00, 0x51, 0x44}, port.writeData)
+	assert.Equal(t, uint16(2), s.DiagnosticCounters().BusMessageCount())
+	assert.Equal(t, uint16(1), s.DiagnosticCounters().ServerMessageCount())
+}
+
+func TestForceListenOnlyMode(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{
+			0x04, 0x08, 0x00, 0x04, 0x00, 0x00, 0xA1, 0x9F,
+			0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+		},
+	}
+	s, err := newModbusServerWithHandler(logger, port, 0x04, server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024))
+	assert.NoError(t, err)
+
+	s.Start()
+	assert.Eventually(t, func() bool { return s.DiagnosticCounters().ServerNoResponseCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	err = s.Close()
+	assert.NoError(t, err)
+	assert.True(t, s.IsListenOnly())
+	assert.Nil(t, port.writeData)
+}
+
+func TestSerialLineStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		exceptionStatus serial.ExceptionStatusSource
+		request         []byte
+		response        []byte
+	}{
+		{
+			name:     "ReadExceptionStatus_NoSource",
+			request:  []byte{0x04, 0x07, 0x42, 0xB2},
+			response: []byte{0x04, 0x07, 0x00, 0x32, 0x31},
+		},
+		{
+			name:            "ReadExceptionStatus",
+			exceptionStatus: serial.ExceptionStatusFunc(func() byte { return 0x3A }),
+			request:         []byte{0x04, 0x07, 0x42, 0xB2},
+			response:        []byte{0x04, 0x07, 0x3A, 0xB2, 0x22},
+		},
+		{
+			name: "GetCommEventCounter",
+			request: []byte{
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+				0x04, 0x0B, 0x42, 0xB7,
+			},
+			response: []byte{0x04, 0x0B, 0x00, 0x00, 0x00, 0x01, 0x65, 0x9E},
+		},
+		{
+			name: "GetCommEventLog",
+			request: []byte{
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9F,
+				0x04, 0x0C, 0x03, 0x75,
+			},
+			response: []byte{0x04, 0x0C, 0x09, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x80, 0x40, 0x80, 0x4B, 0x53},
+		},
+		{
+			name: "GetCommEventLog_Errors",
+			request: []byte{
+				0x04, 0x01, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x9E,
+				0x04, 0x08, 0x00, 0x20, 0x00, 0x00, 0xE1, 0x94,
+				0x04, 0x0C, 0x03, 0x75,
+			},
+			response: []byte{0x04, 0x0C, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x80, 0x41, 0x80, 0x82, 0xDD, 0x12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte(tt.request),
+			}
+			s, err := newModbusServerWithHandler(logger, port, 0x04, server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024))
+			assert.NoError(t, err)
+			if tt.exceptionStatus != nil {
+				s.SetExceptionStatusSource(tt.exceptionStatus)
+			}
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+func TestReportServerID(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverID     []byte
+		runIndicator bool
+		response     []byte
+	}{
+		{
+			name:         "Running",
+			serverID:     []byte("PUMP-01"),
+			runIndicator: true,
+			response:     []byte{0x04, 0x11, 0x08, 0x50, 0x55, 0x4D, 0x50, 0x2D, 0x30, 0x31, 0xFF, 0xB7, 0x1F},
+		},
+		{
+			name:     "NoServerID_Stopped",
+			response: []byte{0x04, 0x11, 0x01, 0x00, 0x50, 0x81},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte{0x04, 0x11, 0xC3, 0x7C},
+			}
+			serverSettings := &settings.ServerSettings{
+				Address:      0x04,
+				ServerID:     tt.serverID,
+				RunIndicator: tt.runIndicator,
+			}
+			handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+			s, err := serial.NewModbusSerialServerWithTransport(logger, serverSettings, handler, rtu.NewModbusServerTransport(port, logger, 0x04))
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+func TestReadFIFOQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		register bool
+		response []byte
+	}{
+		{
+			name:     "Valid",
+			register: true,
+			response: []byte{0x04, 0x18, 0x00, 0x06, 0x00, 0x02, 0x01, 0xB8, 0x12, 0x84, 0x09, 0x08},
+		},
+		{
+			name:     "NotRegistered",
+			response: []byte{0x04, 0x98, 0x02, 0xDA, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte{0x04, 0x18, 0x04, 0xDE, 0x03, 0x8B},
+			}
+			handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+			if tt.register {
+				queues := handler.(*server.DefaultHandler).FIFOQueues
+				queues.Register(0x04DE)
+				assert.NoError(t, queues.Push(0x04DE, 0x01B8, 0x1284))
+			}
+			s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+func TestReadFileRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		createFile bool
+		response   []byte
+	}{
+		{
+			name:       "Valid",
+			createFile: true,
+			response:   []byte{0x04, 0x14, 0x0C, 0x05, 0x06, 0x0D, 0xFE, 0x00, 0x20, 0x05, 0x06, 0x33, 0xCD, 0x00, 0x40, 0xBC, 0xA2},
+		},
+		{
+			name:     "MissingFile",
+			response: []byte{0x04, 0x94, 0x02, 0xDF, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: []byte{0x04, 0x14, 0x0E, 0x06, 0x00, 0x04, 0x00, 0x01, 0x00, 0x02, 0x06, 0x00, 0x03, 0x00, 0x09, 0x00, 0x02, 0xE7, 0xAC},
+			}
+			handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+			if tt.createFile {
+				files := server.NewMemoryFileStore()
+				assert.NoError(t, files.CreateFile(4, 10))
+				assert.NoError(t, files.CreateFile(3, 20))
+				assert.NoError(t, files.WriteRecords(4, 1, []uint16{0x0DFE, 0x0020}))
+				assert.NoError(t, files.WriteRecords(3, 9, []uint16{0x33CD, 0x0040}))
+				handler.(*server.DefaultHandler).Files = files
+			}
+			s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
+
+func TestWriteFileRecord(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	port := &testSerialPort{
+		readData: []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58},
+	}
+	handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+	files := server.NewMemoryFileStore()
+	assert.NoError(t, files.CreateFile(4, 10))
+	handler.(*server.DefaultHandler).Files = files
+	s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+	assert.NoError(t, err)
+
+	s.Start()
+	assert.NoError(t, err)
+
+	expected := []byte{0x04, 0x15, 0x0D, 0x06, 0x00, 0x04, 0x00, 0x07, 0x00, 0x03, 0x06, 0xAF, 0x04, 0xBE, 0x10, 0x0D, 0x15, 0x58}
+	waitForWrite(port, len(expected))
+
+	err = s.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, port.writeData)
+	values, err := files.ReadRecords(4, 7, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{0x06AF, 0x04BE, 0x100D}, values)
+}
+
+func TestCustomFunction(t *testing.T) {
+	assert.NoError(t, data.RegisterCustomFunction(data.CustomFunction{
+		FunctionCode:   0x42,
+		RequestLength:  data.FixedFrameLength(2),
+		ResponseLength: data.ByteCountFrameLength,
+		Handler: func(request data.ModbusCustomOperation) (data.ModbusCustomOperation, error) {
+			switch request.Bytes()[1] {
+			case 0x01:
+			case 0x03:
+				return nil, nil
+			default:
+				return nil, common.ErrIllegalFunction
+			}
+			return data.NewCustomOperation(0x42, []byte{0x02, 0xAB, 0xCD}), nil
+		},
+	}))
+	t.Cleanup(func() { data.UnregisterCustomFunction(0x42) })
+
+	tests := []struct {
+		name     string
+		request  []byte
+		response []byte
+	}{
+		{
+			name:     "Valid",
+			request:  []byte{0x04, 0x42, 0x00, 0x01, 0x60, 0xC0},
+			response: []byte{0x04, 0x42, 0x02, 0xAB, 0xCD, 0xDE, 0xDD},
+		},
+		{
+			name:     "HandlerError",
+			request:  []byte{0x04, 0x42, 0x00, 0x02, 0x20, 0xC1},
+			response: []byte{0x04, 0xC2, 0x01, 0xA0, 0xA1},
+		},
+		{
+			name:     "NilResult",
+			request:  []byte{0x04, 0x42, 0x00, 0x03, 0xE1, 0x01},
+			response: []byte{0x04, 0xC2, 0x04, 0x60, 0xA2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zaptest.NewLogger(t)
+			port := &testSerialPort{
+				readData: tt.request,
+			}
+			handler := server.NewDefaultHandler(logger, 1024, 1024, 1024, 1024)
+			s, err := newModbusServerWithHandler(logger, port, 0x04, handler)
+			assert.NoError(t, err)
+
+			s.Start()
+			assert.NoError(t, err)
+
+			waitForWrite(port, len(tt.response))
+
+			err = s.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.response, port.writeData)
+		})
+	}
+}
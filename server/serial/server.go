@@ -2,42 +2,73 @@ package serial
 
 import (
 	"context"
+	"errors"
 	"io"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/server"
 	settings "github.com/rinzlerlabs/gomodbus/settings/serial"
 	"github.com/rinzlerlabs/gomodbus/transport"
+	"github.com/rinzlerlabs/gomodbus/transport/serial"
 	"go.uber.org/zap"
 )
 
+// maxUnitAddress is the highest address a device on a serial bus can have, the ones above it are reserved
+const maxUnitAddress = 247
+
 type ModbusSerialServer interface {
 	server.ModbusServer
 	Handler() server.RequestHandler
+	DiagnosticCounters() *DiagnosticCounters
+	IsListenOnly() bool
+	CommEventLog() *CommEventLog
+	// SetExceptionStatusSource sets where Read Exception Status gets its 8 status bits from, without one they are all 0
+	SetExceptionStatusSource(source ExceptionStatusSource)
+	// SetUnitHandler answers requests to address with handler, as if it were another device on the bus. The handler
+	// given when the server was created is the unit handler of the server's own address. The serial line functions, like
+	// Diagnostics and Report Server ID, are answered by the server itself for every address.
+	SetUnitHandler(address uint16, handler server.RequestHandler) error
+	// RemoveUnitHandler stops answering requests to address, unless there is a fallback handler.
+	RemoveUnitHandler(address uint16)
+	// SetFallbackHandler answers requests to every address without a unit handler of its own, nil stops answering them.
+	SetFallbackHandler(handler server.RequestHandler)
 }
 
-func NewModbusSerialServerWithTransport(logger *zap.Logger, serverSettings *settings.ServerSettings, handler server.RequestHandler, transport transport.Transport) (ModbusSerialServer, error) {
+func NewModbusSerialServerWithTransport(logger *zap.Logger, serverSettings *settings.ServerSettings, handler server.RequestHandler, t transport.Transport) (ModbusSerialServer, error) {
 	if handler == nil {
 		return nil, common.ErrHandlerRequired
 	}
-	if transport == nil {
+	if t == nil {
 		return nil, common.ErrTransportRequired
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	return &modbusSerialServer{
+	s := &modbusSerialServer{
 		logger:         logger,
-		handler:        handler,
+		handlers:       map[uint16]server.RequestHandler{serverSettings.Address: handler},
 		cancelCtx:      ctx,
 		cancel:         cancel,
 		serverSettings: serverSettings,
-		transport:      transport,
+		transport:      t,
 		stats:          server.NewServerStats(),
-	}, nil
+		counters:       &DiagnosticCounters{},
+		eventLog:       &CommEventLog{},
+	}
+	// Let the transport skip the frames of every address we don't answer
+	if f, ok := t.(transport.FilteringTransport); ok {
+		f.SetAddressFilter(s.accepts)
+	}
+	return s, nil
 }
 
 type modbusSerialServer struct {
-	handler          server.RequestHandler
+	// handlers is the handler of each unit address we answer, fallback answers the rest when it is set
+	handlers         map[uint16]server.RequestHandler
+	fallback         server.RequestHandler
+	handlersMu       sync.RWMutex
 	cancelCtx        context.Context
 	cancel           context.CancelFunc
 	logger           *zap.Logger
@@ -48,6 +79,10 @@ type modbusSerialServer struct {
 	isRunning        bool
 	wg               sync.WaitGroup
 	stats            *server.ServerStats
+	counters         *DiagnosticCounters
+	listenOnly       atomic.Bool
+	eventLog         *CommEventLog
+	exceptionStatus  atomic.Pointer[ExceptionStatusSource]
 }
 
 func (s *modbusSerialServer) IsRunning() bool {
@@ -96,13 +131,82 @@ func (s *modbusSerialServer) Close() error {
 }
 
 func (s *modbusSerialServer) Handler() server.RequestHandler {
-	return s.handler
+	handler, _ := s.handlerFor(s.serverSettings.Address)
+	return handler
+}
+
+func (s *modbusSerialServer) SetUnitHandler(address uint16, handler server.RequestHandler) error {
+	if handler == nil {
+		return common.ErrHandlerRequired
+	}
+	if address == serial.BroadcastAddress || address > maxUnitAddress {
+		return common.ErrInvalidAddress
+	}
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[address] = handler
+	return nil
+}
+
+func (s *modbusSerialServer) RemoveUnitHandler(address uint16) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	delete(s.handlers, address)
+}
+
+func (s *modbusSerialServer) SetFallbackHandler(handler server.RequestHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.fallback = handler
+}
+
+// handlerFor returns the handler that answers requests to address
+func (s *modbusSerialServer) handlerFor(address uint16) (server.RequestHandler, bool) {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+	if handler, ok := s.handlers[address]; ok {
+		return handler, true
+	}
+	return s.fallback, s.fallback != nil
+}
+
+// accepts reports whether requests to address are ours to carry out
+func (s *modbusSerialServer) accepts(address uint16) bool {
+	if address == serial.BroadcastAddress {
+		return true
+	}
+	_, ok := s.handlerFor(address)
+	return ok
 }
 
 func (s *modbusSerialServer) Stats() *server.ServerStats {
 	return s.stats
 }
 
+func (s *modbusSerialServer) DiagnosticCounters() *DiagnosticCounters {
+	return s.counters
+}
+
+func (s *modbusSerialServer) IsListenOnly() bool {
+	return s.listenOnly.Load()
+}
+
+func (s *modbusSerialServer) CommEventLog() *CommEventLog {
+	return s.eventLog
+}
+
+func (s *modbusSerialServer) SetExceptionStatusSource(source ExceptionStatusSource) {
+	s.exceptionStatus.Store(&source)
+}
+
+func (s *modbusSerialServer) readExceptionStatus() byte {
+	source := s.exceptionStatus.Load()
+	if source == nil || *source == nil {
+		return 0x00
+	}
+	return (*source).ExceptionStatus()
+}
+
 func (s *modbusSerialServer) run() {
 	s.logger.Debug("Starting Modbus Serial listener loop")
 
@@ -128,38 +232,146 @@ func (s *modbusSerialServer) run() {
 				continue
 			} else if err == common.ErrNotOurAddress {
 				s.logger.Debug("Received request for different address")
+				s.counters.addBusMessage()
 				continue
 			} else if err == common.ErrUnsupportedFunctionCode {
 				s.logger.Debug("Received request with unsupported function code, this is likely a timing error")
 				continue
 			} else if err == common.ErrInvalidChecksum {
 				s.logger.Debug("Received request with invalid checksum", zap.Error(err))
+				s.counters.addBusCommunicationError()
+				s.eventLog.addReceiveEvent(data.ReceiveEventCommunicationError)
 				continue
 			} else if err != nil {
 				s.logger.Error("Failed to accept request", zap.Error(err))
 				continue
 			}
+			s.counters.addBusMessage()
+			s.counters.addServerMessage()
+			s.eventLog.addReceiveEvent(s.receiveEventFlags(op))
 			s.stats.AddRequest(op)
-			resp, err := s.handler.Handle(op)
+			resp, err := s.handle(op)
 			if err != nil {
 				s.stats.AddError(err)
 				s.logger.Error("Failed to handle request", zap.Error(err))
 			}
+			// Broadcasts are carried out but never answered
+			if resp == nil || op.Header().(transport.SerialHeader).Address() == serial.BroadcastAddress {
+				s.counters.addServerNoResponse()
+				continue
+			}
+			if resp.FunctionCode().IsException() {
+				s.counters.addBusExceptionError()
+			}
 			if err := s.transport.WriteResponseFrame(op.Header(), resp); err != nil {
 				s.stats.AddError(err)
 				s.logger.Error("Failed to write response", zap.Error(err))
+				continue
+			}
+			s.eventLog.addSendEvent(sendEventFlags(resp.Operation()))
+			// Fetching the event counter doesn't count as an event, otherwise a master couldn't poll it for changes
+			if !resp.FunctionCode().IsException() && resp.FunctionCode() != data.GetCommEventCounter {
+				s.eventLog.incrementEventCount()
 			}
 		}
 	}
 }
 
+func (s *modbusSerialServer) receiveEventFlags(adu transport.ApplicationDataUnit) byte {
+	flags := byte(0x00)
+	if s.listenOnly.Load() {
+		flags |= data.ReceiveEventListenOnlyMode
+	}
+	if adu.Header().(transport.SerialHeader).Address() == 0 {
+		flags |= data.ReceiveEventBroadcast
+	}
+	return flags
+}
+
+// handle answers the serial line functions itself since they report on the state of the line, everything else goes to the handler.
+// While in listen only mode only a Restart Communications request is acted on and nothing is answered.
+func (s *modbusSerialServer) handle(adu transport.ApplicationDataUnit) (*transport.ProtocolDataUnit, error) {
+	request, isDiagnostics := adu.PDU().Operation().(data.ModbusDiagnosticsRequest)
+	if s.listenOnly.Load() && (!isDiagnostics || request.SubFunction() != data.RestartCommunicationsOption) {
+		s.logger.Debug("Listen only mode, ignoring request")
+		s.eventLog.addSendEvent(data.SendEventListenOnlyMode)
+		return nil, nil
+	}
+	var result data.ModbusOperation
+	switch adu.PDU().FunctionCode() {
+	case data.Diagnostics:
+		result = s.handleDiagnostics(request)
+	case data.ReadExceptionStatus:
+		result = data.NewReadExceptionStatusResponse(s.readExceptionStatus())
+	case data.GetCommEventCounter:
+		// We never run program commands, so we are never busy
+		result = data.NewGetCommEventCounterResponse(0x0000, s.eventLog.EventCount())
+	case data.GetCommEventLog:
+		result = data.NewGetCommEventLogResponse(0x0000, s.eventLog.EventCount(), s.counters.counter(data.ReturnBusMessageCount), s.eventLog.Events())
+	case data.ReportServerID:
+		result = data.NewReportServerIDResponse(s.serverSettings.ServerID, s.serverSettings.RunIndicator)
+	default:
+		return s.dispatch(adu)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return transport.NewProtocolDataUnit(result), nil
+}
+
+// broadcastable reports whether a function can be broadcast, nobody answers a broadcast so only writes can be
+func broadcastable(functionCode data.FunctionCode) bool {
+	switch functionCode {
+	case data.WriteSingleCoil, data.WriteSingleRegister, data.WriteMultipleCoils, data.WriteMultipleRegisters, data.MaskWriteRegister, data.WriteFileRecord:
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatch passes a request to the handler of its address, a broadcast write is carried out once by every handler
+func (s *modbusSerialServer) dispatch(adu transport.ApplicationDataUnit) (*transport.ProtocolDataUnit, error) {
+	address := adu.Header().(transport.SerialHeader).Address()
+	if address != serial.BroadcastAddress {
+		handler, ok := s.handlerFor(address)
+		if !ok {
+			// The handler was removed since the request was accepted
+			return nil, common.ErrNotOurAddress
+		}
+		return handler.Handle(adu)
+	}
+	if !broadcastable(adu.PDU().FunctionCode()) {
+		s.logger.Debug("Ignoring broadcast of a function that isn't a write", zap.Uint8("functionCode", uint8(adu.PDU().FunctionCode())))
+		return nil, nil
+	}
+	s.handlersMu.RLock()
+	// The same handler can answer several addresses, it still only carries out the write once
+	var handlers []server.RequestHandler
+	for _, handler := range s.handlers {
+		if !slices.Contains(handlers, handler) {
+			handlers = append(handlers, handler)
+		}
+	}
+	if s.fallback != nil && !slices.Contains(handlers, s.fallback) {
+		handlers = append(handlers, s.fallback)
+	}
+	s.handlersMu.RUnlock()
+	var errs []error
+	for _, handler := range handlers {
+		if _, err := handler.Handle(adu); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
 func (s *modbusSerialServer) acceptAndValidateTransaction() (transport.ApplicationDataUnit, error) {
 	txn, err := s.transport.ReadRequest(s.cancelCtx)
 	if err != nil {
 		return txn, err
 	}
 
-	if txn.Header().(transport.SerialHeader).Address() != s.serverSettings.Address {
+	if !s.accepts(txn.Header().(transport.SerialHeader).Address()) {
 		return nil, common.ErrNotOurAddress
 	}
 	return txn, nil
@@ -12,19 +12,31 @@ func NewServerStats() *ServerStats {
 }
 
 type ServerStats struct {
-	TotalRequests                       uint64
-	TotalErrors                         uint64
-	TotalClients                        uint64
-	TotalReadCoilsRequests              uint64
-	TotalReadDiscreteInputsRequests     uint64
-	TotalReadHoldingRegistersRequests   uint64
-	TotalReadInputRegistersRequests     uint64
-	TotalWriteSingleCoilRequests        uint64
-	TotalWriteSingleRegisterRequests    uint64
-	TotalWriteMultipleCoilsRequests     uint64
-	TotalWriteMultipleRegistersRequests uint64
-	LastErrors                          []error
-	mu                                  sync.Mutex
+	TotalRequests                           uint64
+	TotalErrors                             uint64
+	TotalClients                            uint64
+	TotalReadCoilsRequests                  uint64
+	TotalReadDiscreteInputsRequests         uint64
+	TotalReadHoldingRegistersRequests       uint64
+	TotalReadInputRegistersRequests         uint64
+	TotalWriteSingleCoilRequests            uint64
+	TotalWriteSingleRegisterRequests        uint64
+	TotalReadExceptionStatusRequests        uint64
+	TotalDiagnosticsRequests                uint64
+	TotalGetCommEventCounterRequests        uint64
+	TotalGetCommEventLogRequests            uint64
+	TotalWriteMultipleCoilsRequests         uint64
+	TotalWriteMultipleRegistersRequests     uint64
+	TotalReportServerIDRequests             uint64
+	TotalReadFileRecordRequests             uint64
+	TotalWriteFileRecordRequests            uint64
+	TotalMaskWriteRegisterRequests          uint64
+	TotalReadWriteMultipleRegistersRequests uint64
+	TotalReadFIFOQueueRequests              uint64
+	TotalReadDeviceIdentificationRequests   uint64
+	TotalCustomFunctionRequests             uint64
+	LastErrors                              []error
+	mu                                      sync.Mutex
 }
 
 func (s *ServerStats) AddRequest(txn transport.ApplicationDataUnit) {
@@ -44,10 +56,34 @@ func (s *ServerStats) AddRequest(txn transport.ApplicationDataUnit) {
 		s.TotalWriteSingleCoilRequests++
 	case data.WriteSingleRegister:
 		s.TotalWriteSingleRegisterRequests++
+	case data.ReadExceptionStatus:
+		s.TotalReadExceptionStatusRequests++
+	case data.Diagnostics:
+		s.TotalDiagnosticsRequests++
+	case data.GetCommEventCounter:
+		s.TotalGetCommEventCounterRequests++
+	case data.GetCommEventLog:
+		s.TotalGetCommEventLogRequests++
 	case data.WriteMultipleCoils:
 		s.TotalWriteMultipleCoilsRequests++
 	case data.WriteMultipleRegisters:
 		s.TotalWriteMultipleRegistersRequests++
+	case data.ReportServerID:
+		s.TotalReportServerIDRequests++
+	case data.ReadFileRecord:
+		s.TotalReadFileRecordRequests++
+	case data.WriteFileRecord:
+		s.TotalWriteFileRecordRequests++
+	case data.MaskWriteRegister:
+		s.TotalMaskWriteRegisterRequests++
+	case data.ReadWriteMultipleRegisters:
+		s.TotalReadWriteMultipleRegistersRequests++
+	case data.ReadFIFOQueue:
+		s.TotalReadFIFOQueueRequests++
+	case data.EncapsulatedInterfaceTransport:
+		s.TotalReadDeviceIdentificationRequests++
+	default:
+		s.TotalCustomFunctionRequests++
 	}
 }
 
@@ -65,17 +101,29 @@ func (s *ServerStats) AsMap() map[string]interface{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return map[string]interface{}{
-		"TotalRequests":                       s.TotalRequests,
-		"TotalErrors":                         s.TotalErrors,
-		"TotalClients":                        s.TotalClients,
-		"TotalReadCoilsRequests":              s.TotalReadCoilsRequests,
-		"TotalReadDiscreteInputsRequests":     s.TotalReadDiscreteInputsRequests,
-		"TotalReadHoldingRegistersRequests":   s.TotalReadHoldingRegistersRequests,
-		"TotalReadInputRegistersRequests":     s.TotalReadInputRegistersRequests,
-		"TotalWriteSingleCoilRequests":        s.TotalWriteSingleCoilRequests,
-		"TotalWriteSingleRegisterRequests":    s.TotalWriteSingleRegisterRequests,
-		"TotalWriteMultipleCoilsRequests":     s.TotalWriteMultipleCoilsRequests,
-		"TotalWriteMultipleRegistersRequests": s.TotalWriteMultipleRegistersRequests,
-		"LastErrors":                          s.LastErrors,
+		"TotalRequests":                           s.TotalRequests,
+		"TotalErrors":                             s.TotalErrors,
+		"TotalClients":                            s.TotalClients,
+		"TotalReadCoilsRequests":                  s.TotalReadCoilsRequests,
+		"TotalReadDiscreteInputsRequests":         s.TotalReadDiscreteInputsRequests,
+		"TotalReadHoldingRegistersRequests":       s.TotalReadHoldingRegistersRequests,
+		"TotalReadInputRegistersRequests":         s.TotalReadInputRegistersRequests,
+		"TotalWriteSingleCoilRequests":            s.TotalWriteSingleCoilRequests,
+		"TotalWriteSingleRegisterRequests":        s.TotalWriteSingleRegisterRequests,
+		"TotalReadExceptionStatusRequests":        s.TotalReadExceptionStatusRequests,
+		"TotalDiagnosticsRequests":                s.TotalDiagnosticsRequests,
+		"TotalGetCommEventCounterRequests":        s.TotalGetCommEventCounterRequests,
+		"TotalGetCommEventLogRequests":            s.TotalGetCommEventLogRequests,
+		"TotalWriteMultipleCoilsRequests":         s.TotalWriteMultipleCoilsRequests,
+		"TotalWriteMultipleRegistersRequests":     s.TotalWriteMultipleRegistersRequests,
+		"TotalReportServerIDRequests":             s.TotalReportServerIDRequests,
+		"TotalReadFileRecordRequests":             s.TotalReadFileRecordRequests,
+		"TotalWriteFileRecordRequests":            s.TotalWriteFileRecordRequests,
+		"TotalMaskWriteRegisterRequests":          s.TotalMaskWriteRegisterRequests,
+		"TotalReadWriteMultipleRegistersRequests": s.TotalReadWriteMultipleRegistersRequests,
+		"TotalReadFIFOQueueRequests":              s.TotalReadFIFOQueueRequests,
+		"TotalReadDeviceIdentificationRequests":   s.TotalReadDeviceIdentificationRequests,
+		"TotalCustomFunctionRequests":             s.TotalCustomFunctionRequests,
+		"LastErrors":                              s.LastErrors,
 	}
 }
@@ -2,6 +2,8 @@ package network
 
 import (
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rinzlerlabs/gomodbus/common"
@@ -27,6 +29,25 @@ type ClientSettings struct {
 	NetworkSettings
 	ResponseTimeout time.Duration
 	DialTimeout     time.Duration
+	// MaxInFlight is the number of requests that can be outstanding on the connection at once, anything above 1 turns on
+	// pipelining. The device has to support it, most handle somewhere between 1 and 16.
+	MaxInFlight int
+	// Reconnect redials the endpoint as soon as the connection fails, then waits ReconnectMinBackoff after the first failed
+	// attempt and doubles the wait after every failure up to ReconnectMaxBackoff. Unset waits default to 500ms and 30s.
+	Reconnect           bool
+	ReconnectMinBackoff time.Duration
+	ReconnectMaxBackoff time.Duration
+	// ReconnectJitter randomizes each wait by up to this fraction of it, so a fleet of clients doesn't redial in lockstep.
+	ReconnectJitter float64
+	// RetryAttempts is the most times a call is made before its error is returned, 1 turns retries off.
+	RetryAttempts int
+	// RetryBackoff is the wait before the first retry, it doubles for every retry after that.
+	RetryBackoff time.Duration
+	// RetryWrites allows calls that change the device to be retried, only turn it on if repeating a write is harmless.
+	RetryWrites bool
+	// RetryOn is the errors that are retried, when it is nil timeouts, checksum errors and the busy and acknowledge
+	// exceptions are.
+	RetryOn []error
 }
 
 func (c *ClientSettings) parseValuesFromURI(u *url.URL) error {
@@ -39,6 +60,42 @@ func (c *ClientSettings) parseValuesFromURI(u *url.URL) error {
 	if err := parseFieldDurationFromURL(u, "dialTimeout", &c.DialTimeout, 5*time.Second); err != nil {
 		return err
 	}
+	if err := parseFieldIntFromURL(u, "maxInFlight", &c.MaxInFlight, 1); err != nil {
+		return err
+	}
+	if c.MaxInFlight < 1 {
+		return common.ErrInvalidValue
+	}
+	if err := parseFieldBoolFromURL(u, "reconnect", &c.Reconnect, false); err != nil {
+		return err
+	}
+	if err := parseFieldDurationFromURL(u, "reconnectMinBackoff", &c.ReconnectMinBackoff, 500*time.Millisecond); err != nil {
+		return err
+	}
+	if err := parseFieldDurationFromURL(u, "reconnectMaxBackoff", &c.ReconnectMaxBackoff, 30*time.Second); err != nil {
+		return err
+	}
+	if err := parseFieldFloatFromURL(u, "reconnectJitter", &c.ReconnectJitter, 0.2); err != nil {
+		return err
+	}
+	if c.ReconnectMinBackoff <= 0 || c.ReconnectMaxBackoff < c.ReconnectMinBackoff || c.ReconnectJitter < 0 || c.ReconnectJitter > 1 {
+		return common.ErrInvalidValue
+	}
+	if err := parseFieldIntFromURL(u, "retryAttempts", &c.RetryAttempts, 1); err != nil {
+		return err
+	}
+	if err := parseFieldDurationFromURL(u, "retryBackoff", &c.RetryBackoff, 100*time.Millisecond); err != nil {
+		return err
+	}
+	if err := parseFieldBoolFromURL(u, "retryWrites", &c.RetryWrites, false); err != nil {
+		return err
+	}
+	if err := parseFieldErrorsFromURL(u, "retryOn", &c.RetryOn); err != nil {
+		return err
+	}
+	if c.RetryAttempts < 1 || c.RetryBackoff < 0 {
+		return common.ErrInvalidValue
+	}
 	return nil
 }
 
@@ -66,6 +123,63 @@ func parseFieldDurationFromURL(u *url.URL, field string, settingsField *time.Dur
 	return nil
 }
 
+func parseFieldIntFromURL(u *url.URL, field string, settingsField *int, defaultValue int) error {
+	if value := u.Query().Get(field); value != "" {
+		parsedValue, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*settingsField = parsedValue
+	} else {
+		*settingsField = defaultValue
+	}
+	return nil
+}
+
+func parseFieldBoolFromURL(u *url.URL, field string, settingsField *bool, defaultValue bool) error {
+	if value := u.Query().Get(field); value != "" {
+		parsedValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*settingsField = parsedValue
+	} else {
+		*settingsField = defaultValue
+	}
+	return nil
+}
+
+func parseFieldFloatFromURL(u *url.URL, field string, settingsField *float64, defaultValue float64) error {
+	if value := u.Query().Get(field); value != "" {
+		parsedValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*settingsField = parsedValue
+	} else {
+		*settingsField = defaultValue
+	}
+	return nil
+}
+
+// parseFieldErrorsFromURL parses a comma separated list of the names in common.RetryableErrors
+func parseFieldErrorsFromURL(u *url.URL, field string, settingsField *[]error) error {
+	value := u.Query().Get(field)
+	if value == "" {
+		return nil
+	}
+	var errs []error
+	for _, name := range strings.Split(value, ",") {
+		err, ok := common.RetryableErrors[name]
+		if !ok {
+			return common.ErrInvalidValue
+		}
+		errs = append(errs, err)
+	}
+	*settingsField = errs
+	return nil
+}
+
 func NewClientSettingsFromURI(uri string) (*ClientSettings, error) {
 	if uri == "" {
 		return nil, common.ErrURIIsNil
@@ -122,8 +236,14 @@ func DefaultClientSettings(endpoint string) (*ClientSettings, error) {
 			Endpoint:  u,
 			KeepAlive: 30 * time.Second,
 		},
-		ResponseTimeout: 5 * time.Second,
-		DialTimeout:     5 * time.Second,
+		ResponseTimeout:     5 * time.Second,
+		DialTimeout:         5 * time.Second,
+		MaxInFlight:         1,
+		ReconnectMinBackoff: 500 * time.Millisecond,
+		ReconnectMaxBackoff: 30 * time.Second,
+		ReconnectJitter:     0.2,
+		RetryAttempts:       1,
+		RetryBackoff:        100 * time.Millisecond,
 	}, nil
 }
 
@@ -141,8 +261,14 @@ func NewClientSettings(endpoint string, dialTimeout, responseTimeout, keepAlive
 			Endpoint:  u,
 			KeepAlive: keepAlive,
 		},
-		ResponseTimeout: responseTimeout,
-		DialTimeout:     dialTimeout,
+		ResponseTimeout:     responseTimeout,
+		DialTimeout:         dialTimeout,
+		MaxInFlight:         1,
+		ReconnectMinBackoff: 500 * time.Millisecond,
+		ReconnectMaxBackoff: 30 * time.Second,
+		ReconnectJitter:     0.2,
+		RetryAttempts:       1,
+		RetryBackoff:        100 * time.Millisecond,
 	}, nil
 }
 
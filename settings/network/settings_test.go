@@ -1,6 +1,7 @@
 package network
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
@@ -15,6 +16,11 @@ func TestNewClientSettings(t *testing.T) {
 		responseTimeout time.Duration
 		dialTimeout     time.Duration
 		keepAlive       time.Duration
+		maxInFlight     int
+		reconnect       bool
+		minBackoff      time.Duration
+		maxBackoff      time.Duration
+		jitter          float64
 		scheme          string
 		host            string
 		err             error
@@ -36,19 +42,52 @@ func TestNewClientSettings(t *testing.T) {
 			responseTimeout: 1 * time.Second,
 			dialTimeout:     5 * time.Second,
 			keepAlive:       30 * time.Second,
+			maxInFlight:     1,
+			minBackoff:      500 * time.Millisecond,
+			maxBackoff:      30 * time.Second,
+			jitter:          0.2,
 			scheme:          "tcp",
 			host:            ":502",
 		},
 		{
 			name:            "Custom values",
-			uri:             "tcp://:502?responseTimeout=2s&dialTimeout=10s&keepAlive=60s",
+			uri:             "tcp://:502?responseTimeout=2s&dialTimeout=10s&keepAlive=60s&maxInFlight=8",
 			err:             nil,
 			responseTimeout: 2 * time.Second,
 			dialTimeout:     10 * time.Second,
 			keepAlive:       60 * time.Second,
+			maxInFlight:     8,
 			scheme:          "tcp",
 			host:            ":502",
 		},
+		{
+			name: "Invalid maxInFlight",
+			uri:  "tcp://:502?maxInFlight=0",
+			err:  common.ErrInvalidValue,
+		},
+		{
+			name:       "Reconnect",
+			uri:        "tcp://:502?reconnect=true&reconnectMinBackoff=100ms&reconnectMaxBackoff=5s&reconnectJitter=0.5",
+			reconnect:  true,
+			minBackoff: 100 * time.Millisecond,
+			maxBackoff: 5 * time.Second,
+			jitter:     0.5,
+		},
+		{
+			name: "Invalid reconnect",
+			uri:  "tcp://:502?reconnect=maybe",
+			err:  strconv.ErrSyntax,
+		},
+		{
+			name: "Max backoff below min backoff",
+			uri:  "tcp://:502?reconnectMinBackoff=5s&reconnectMaxBackoff=1s",
+			err:  common.ErrInvalidValue,
+		},
+		{
+			name: "Invalid reconnectJitter",
+			uri:  "tcp://:502?reconnectJitter=1.5",
+			err:  common.ErrInvalidValue,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -69,6 +108,19 @@ func TestNewClientSettings(t *testing.T) {
 				if tt.keepAlive != 0 {
 					assert.Equal(t, tt.keepAlive, settings.KeepAlive)
 				}
+				if tt.maxInFlight != 0 {
+					assert.Equal(t, tt.maxInFlight, settings.MaxInFlight)
+				}
+				assert.Equal(t, tt.reconnect, settings.Reconnect)
+				if tt.minBackoff != 0 {
+					assert.Equal(t, tt.minBackoff, settings.ReconnectMinBackoff)
+				}
+				if tt.maxBackoff != 0 {
+					assert.Equal(t, tt.maxBackoff, settings.ReconnectMaxBackoff)
+				}
+				if tt.jitter != 0 {
+					assert.Equal(t, tt.jitter, settings.ReconnectJitter)
+				}
 				if tt.scheme != "" {
 					assert.Equal(t, tt.scheme, settings.Endpoint.Scheme)
 				}
@@ -79,3 +131,55 @@ func TestNewClientSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestClientRetrySettings(t *testing.T) {
+	tests := []struct {
+		name          string
+		uri           string
+		retryAttempts int
+		retryBackoff  time.Duration
+		retryWrites   bool
+		retryOn       []error
+		err           error
+	}{
+		{
+			name:          "Default values",
+			uri:           "tcp://:502",
+			retryAttempts: 1,
+			retryBackoff:  100 * time.Millisecond,
+		},
+		{
+			name:          "Custom values",
+			uri:           "tcp://:502?retryAttempts=3&retryBackoff=50ms&retryWrites=true&retryOn=timeout,gatewayTargetFailed",
+			retryAttempts: 3,
+			retryBackoff:  50 * time.Millisecond,
+			retryWrites:   true,
+			retryOn:       []error{common.ErrTimeout, common.ErrGatewayTargetDeviceFailedToRespond},
+		},
+		{
+			name: "Invalid retryAttempts",
+			uri:  "tcp://:502?retryAttempts=0",
+			err:  common.ErrInvalidValue,
+		},
+		{
+			name: "Invalid retryOn",
+			uri:  "tcp://:502?retryOn=timeout,sunspots",
+			err:  common.ErrInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewClientSettingsFromURI(tt.uri)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, settings)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.retryAttempts, settings.RetryAttempts)
+			assert.Equal(t, tt.retryBackoff, settings.RetryBackoff)
+			assert.Equal(t, tt.retryWrites, settings.RetryWrites)
+			assert.Equal(t, tt.retryOn, settings.RetryOn)
+		})
+	}
+}
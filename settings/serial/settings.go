@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	sp "github.com/goburrow/serial"
@@ -75,6 +76,18 @@ func (s *SerialSettings) parseValuesFromURI(u *url.URL) error {
 type ClientSettings struct {
 	SerialSettings
 	ResponseTimeout time.Duration
+	// RetryAttempts is the most times a call is made before its error is returned, 1 turns retries off.
+	RetryAttempts int
+	// RetryBackoff is the wait before the first retry, it doubles for every retry after that.
+	RetryBackoff time.Duration
+	// RetryWrites allows calls that change the device to be retried, only turn it on if repeating a write is harmless.
+	RetryWrites bool
+	// RetryOn is the errors that are retried, when it is nil timeouts, checksum errors and the busy and acknowledge
+	// exceptions are.
+	RetryOn []error
+	// TurnaroundDelay is how long to wait after a broadcast to address 0 before sending the next request, giving the
+	// devices time to carry it out.
+	TurnaroundDelay time.Duration
 }
 
 func (c *ClientSettings) parseValuesFromURI(u *url.URL) error {
@@ -84,12 +97,35 @@ func (c *ClientSettings) parseValuesFromURI(u *url.URL) error {
 	if err := parseFieldDurationFromURI(u, "responseTimeout", &c.ResponseTimeout, 1*time.Second); err != nil {
 		return err
 	}
+	c.RetryAttempts = 1
+	if err := parseIntFieldFromURI(u, "retryAttempts", &c.RetryAttempts); err != nil && !errors.Is(err, common.ErrMissingValue) {
+		return err
+	}
+	if err := parseFieldDurationFromURI(u, "retryBackoff", &c.RetryBackoff, 100*time.Millisecond); err != nil {
+		return err
+	}
+	if err := parseBoolFieldFromURI(u, "retryWrites", &c.RetryWrites, false); err != nil {
+		return err
+	}
+	if err := parseErrorsFieldFromURI(u, "retryOn", &c.RetryOn); err != nil {
+		return err
+	}
+	if err := parseFieldDurationFromURI(u, "turnaroundDelay", &c.TurnaroundDelay, 100*time.Millisecond); err != nil {
+		return err
+	}
+	if c.RetryAttempts < 1 || c.RetryBackoff < 0 || c.TurnaroundDelay < 0 {
+		return common.ErrInvalidValue
+	}
 	return nil
 }
 
 type ServerSettings struct {
 	SerialSettings
 	Address uint16
+	// ServerID is the device specific identifier returned by Report Server ID
+	ServerID []byte
+	// RunIndicator is the run indicator status returned by Report Server ID, true reports ON
+	RunIndicator bool
 }
 
 func (s *ServerSettings) parseValuesFromURI(u *url.URL) error {
@@ -99,6 +135,12 @@ func (s *ServerSettings) parseValuesFromURI(u *url.URL) error {
 	if err := parseUInt16FieldFromURI(u, "address", &s.Address); err != nil {
 		return err
 	}
+	if value := u.Query().Get("serverId"); value != "" {
+		s.ServerID = []byte(value)
+	}
+	if err := parseBoolFieldFromURI(u, "runIndicator", &s.RunIndicator, true); err != nil {
+		return errors.Join(err, common.ErrInvalidValue)
+	}
 	return nil
 }
 
@@ -115,6 +157,19 @@ func parseFieldDurationFromURI(u *url.URL, field string, settingsField *time.Dur
 	return nil
 }
 
+func parseBoolFieldFromURI(u *url.URL, field string, settingsField *bool, defaultValue bool) error {
+	if value := u.Query().Get(field); value != "" {
+		parsedValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*settingsField = parsedValue
+	} else {
+		*settingsField = defaultValue
+	}
+	return nil
+}
+
 func parseIntFieldFromURI(u *url.URL, field string, settingsField *int) error {
 	if value := u.Query().Get(field); value != "" {
 		value, err := strconv.Atoi(value)
@@ -152,6 +207,24 @@ func parseStringFieldFromURI(u *url.URL, field string, settingsField *string, va
 	return common.ErrMissingValue
 }
 
+// parseErrorsFieldFromURI parses a comma separated list of the names in common.RetryableErrors
+func parseErrorsFieldFromURI(u *url.URL, field string, settingsField *[]error) error {
+	value := u.Query().Get(field)
+	if value == "" {
+		return nil
+	}
+	var errs []error
+	for _, name := range strings.Split(value, ",") {
+		err, ok := common.RetryableErrors[name]
+		if !ok {
+			return common.ErrInvalidValue
+		}
+		errs = append(errs, err)
+	}
+	*settingsField = errs
+	return nil
+}
+
 func NewClientSettingsFromURI(uri string) (*ClientSettings, error) {
 	if uri == "" {
 		return nil, common.ErrURIIsNil
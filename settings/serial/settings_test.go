@@ -2,6 +2,7 @@ package serial
 
 import (
 	"testing"
+	"time"
 
 	"github.com/rinzlerlabs/gomodbus/common"
 	"github.com/stretchr/testify/assert"
@@ -131,6 +132,72 @@ func TestNewClientSettings(t *testing.T) {
 	}
 }
 
+func TestClientRetrySettings(t *testing.T) {
+	const base = "rtu:///dev/ttyUSB1?baud=9600&dataBits=8&parity=E&stopBits=2"
+	tests := []struct {
+		name          string
+		query         string
+		retryAttempts int
+		retryBackoff  time.Duration
+		retryWrites   bool
+		retryOn       []error
+		err           error
+	}{
+		{
+			name:          "Default values",
+			retryAttempts: 1,
+			retryBackoff:  100 * time.Millisecond,
+		},
+		{
+			name:          "Custom values",
+			query:         "&retryAttempts=3&retryBackoff=50ms&retryWrites=true&retryOn=timeout,checksum",
+			retryAttempts: 3,
+			retryBackoff:  50 * time.Millisecond,
+			retryWrites:   true,
+			retryOn:       []error{common.ErrTimeout, common.ErrInvalidChecksum},
+		},
+		{
+			name:  "Invalid retryAttempts",
+			query: "&retryAttempts=0",
+			err:   common.ErrInvalidValue,
+		},
+		{
+			name:  "Invalid retryOn",
+			query: "&retryOn=timeout,sunspots",
+			err:   common.ErrInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewClientSettingsFromURI(base + tt.query)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, settings)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.retryAttempts, settings.RetryAttempts)
+			assert.Equal(t, tt.retryBackoff, settings.RetryBackoff)
+			assert.Equal(t, tt.retryWrites, settings.RetryWrites)
+			assert.Equal(t, tt.retryOn, settings.RetryOn)
+		})
+	}
+}
+
+func TestClientTurnaroundDelay(t *testing.T) {
+	const base = "rtu:///dev/ttyUSB1?baud=9600&dataBits=8&parity=E&stopBits=2"
+	settings, err := NewClientSettingsFromURI(base)
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, settings.TurnaroundDelay)
+
+	settings, err = NewClientSettingsFromURI(base + "&turnaroundDelay=250ms")
+	assert.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, settings.TurnaroundDelay)
+
+	_, err = NewClientSettingsFromURI(base + "&turnaroundDelay=-1s")
+	assert.ErrorIs(t, err, common.ErrInvalidValue)
+}
+
 func TestParseSerialSettingsFromUrl(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -254,3 +321,47 @@ func TestParseSerialSettingsFromUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestNewServerSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		uri          string
+		address      uint16
+		serverID     []byte
+		runIndicator bool
+		err          error
+	}{
+		{
+			name:         "defaults",
+			uri:          "rtu:///dev/ttyUSB1?baud=9600&dataBits=8&parity=E&stopBits=2&address=4",
+			address:      4,
+			runIndicator: true,
+		},
+		{
+			name:         "server id",
+			uri:          "rtu:///dev/ttyUSB1?baud=9600&dataBits=8&parity=E&stopBits=2&address=4&serverId=PUMP-01&runIndicator=false",
+			address:      4,
+			serverID:     []byte("PUMP-01"),
+			runIndicator: false,
+		},
+		{
+			name: "invalid run indicator",
+			uri:  "rtu:///dev/ttyUSB1?baud=9600&dataBits=8&parity=E&stopBits=2&address=4&runIndicator=maybe",
+			err:  common.ErrInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewServerSettingsFromURI(tt.uri)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, settings)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.address, settings.Address)
+			assert.Equal(t, tt.serverID, settings.ServerID)
+			assert.Equal(t, tt.runIndicator, settings.RunIndicator)
+		})
+	}
+}
@@ -59,6 +59,26 @@ func NewProtocolDataUnit(op data.ModbusOperation) *ProtocolDataUnit {
 		f = data.WriteSingleRegister
 	case *data.WriteSingleRegisterResponse:
 		f = data.WriteSingleRegister
+	case *data.ReadExceptionStatusRequest:
+		f = data.ReadExceptionStatus
+	case *data.ReadExceptionStatusResponse:
+		f = data.ReadExceptionStatus
+	case *data.GetCommEventCounterRequest:
+		f = data.GetCommEventCounter
+	case *data.GetCommEventCounterResponse:
+		f = data.GetCommEventCounter
+	case *data.GetCommEventLogRequest:
+		f = data.GetCommEventLog
+	case *data.GetCommEventLogResponse:
+		f = data.GetCommEventLog
+	case *data.ReportServerIDRequest:
+		f = data.ReportServerID
+	case *data.ReportServerIDResponse:
+		f = data.ReportServerID
+	case *data.DiagnosticsRequest:
+		f = data.Diagnostics
+	case *data.DiagnosticsResponse:
+		f = data.Diagnostics
 	case *data.WriteMultipleCoilsRequest:
 		f = data.WriteMultipleCoils
 	case *data.WriteMultipleCoilsResponse:
@@ -67,8 +87,34 @@ func NewProtocolDataUnit(op data.ModbusOperation) *ProtocolDataUnit {
 		f = data.WriteMultipleRegisters
 	case *data.WriteMultipleRegistersResponse:
 		f = data.WriteMultipleRegisters
+	case *data.MaskWriteRegisterRequest:
+		f = data.MaskWriteRegister
+	case *data.MaskWriteRegisterResponse:
+		f = data.MaskWriteRegister
+	case *data.ReadWriteMultipleRegistersRequest:
+		f = data.ReadWriteMultipleRegisters
+	case *data.ReadWriteMultipleRegistersResponse:
+		f = data.ReadWriteMultipleRegisters
+	case *data.ReadFileRecordRequest:
+		f = data.ReadFileRecord
+	case *data.ReadFileRecordResponse:
+		f = data.ReadFileRecord
+	case *data.WriteFileRecordRequest:
+		f = data.WriteFileRecord
+	case *data.WriteFileRecordResponse:
+		f = data.WriteFileRecord
+	case *data.ReadFIFOQueueRequest:
+		f = data.ReadFIFOQueue
+	case *data.ReadFIFOQueueResponse:
+		f = data.ReadFIFOQueue
+	case *data.ReadDeviceIdentificationRequest:
+		f = data.EncapsulatedInterfaceTransport
+	case *data.ReadDeviceIdentificationResponse:
+		f = data.EncapsulatedInterfaceTransport
 	case *data.ModbusOperationException:
 		f = op.FunctionCode
+	case data.ModbusCustomOperation:
+		f = op.FunctionCode()
 	}
 	return &ProtocolDataUnit{
 		functionCode: f,
@@ -95,6 +141,10 @@ func (pdu *ProtocolDataUnit) FunctionCode() data.FunctionCode {
 	return pdu.functionCode
 }
 
-func (pdu *ProtocolDataUnit) Bytes() []byte {
-	return append([]byte{byte(pdu.functionCode)}, data.ModbusOperationToBytes(pdu.op)...)
+func (pdu *ProtocolDataUnit) Bytes() ([]byte, error) {
+	bytes, err := data.ModbusOperationToBytes(pdu.op)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(pdu.functionCode)}, bytes...), nil
 }
@@ -76,7 +76,8 @@ func (m *modbusApplicationDataUnit) Bytes() []byte {
 	bytes := make([]byte, 0)
 	headerBytes := m.Header().Bytes()
 	bytes = append(bytes, headerBytes[:4]...)
-	pduBytes := m.pdu.Bytes()
+	// The operation was checked when the ADU was created
+	pduBytes, _ := m.pdu.Bytes()
 	length := uint16(len(pduBytes)) + 1 //We need to account for the unitId here
 	bytes = append(bytes, byte(length>>8), byte(length&0xFF))
 	bytes = append(bytes, headerBytes[4:]...)
@@ -87,17 +88,51 @@ func (m *modbusApplicationDataUnit) Bytes() []byte {
 	return bytes
 }
 
+const (
+	// The MBAP header is the transaction ID, protocol ID, length and unit ID
+	mbapHeaderLength = 7
+	// The largest PDU is 253 bytes
+	maxFrameLength = mbapHeaderLength + 253
+)
+
+// validateMBAPHeader checks the protocol ID and length of an MBAP header, the length has to cover the unit ID and at
+// least a function code and can't be longer than the largest PDU.
+func validateMBAPHeader(header []byte) error {
+	if len(header) < mbapHeaderLength {
+		return common.ErrInvalidLength
+	}
+	if header[2] != 0x00 || header[3] != 0x00 {
+		return common.ErrInvalidProtocolID
+	}
+	length := int(header[4])<<8 | int(header[5])
+	if length < 2 || mbapHeaderLength+length-1 > maxFrameLength {
+		return common.ErrInvalidLength
+	}
+	return nil
+}
+
+// validateFrame checks the MBAP header of a whole frame and that the length in the header matches the frame.
+func validateFrame(packet []byte) error {
+	if err := validateMBAPHeader(packet); err != nil {
+		return err
+	}
+	// We have to subtract 1 because the unitId is included in the length
+	length := int(packet[4])<<8 | int(packet[5])
+	if len(packet)-mbapHeaderLength != length-1 {
+		return common.ErrInvalidLength
+	}
+	return nil
+}
+
 func ParseModbusRequestFrame(packet []byte) (transport.ApplicationDataUnit, error) {
+	if err := validateFrame(packet); err != nil {
+		return nil, err
+	}
 	txId := packet[0:2]
 	protoId := packet[2:4]
-	expectedLength := int(packet[4])<<8 | int(packet[5])
 	unitId := packet[6]
 	packet = packet[7:]
 
-	// We have to add 1 because the unitId is included in the length
-	if len(packet)+1 != expectedLength {
-		return nil, common.ErrInvalidLength
-	}
 	functionCode := data.FunctionCode(packet[0])
 	op, err := data.ParseModbusRequestOperation(functionCode, packet[1:])
 	if err != nil {
@@ -112,17 +147,22 @@ func ParseModbusRequestFrame(packet []byte) (transport.ApplicationDataUnit, erro
 }
 
 func ParseModbusServerResponseFrame(packet []byte, valueCount int) (transport.ApplicationDataUnit, error) {
+	if err := validateFrame(packet); err != nil {
+		return nil, err
+	}
 	txId := packet[0:2]
 	protoId := packet[2:4]
-	length := packet[4:6]
 	unitId := packet[6]
-	fmt.Printf("txId: %v, protoId: %v, length: %v, unitId: %v\n", txId, protoId, length, unitId)
 	packet = packet[7:]
 	functionCode := data.FunctionCode(packet[0])
 	op, err := data.ParseModbusResponseOperation(functionCode, packet[1:], valueCount)
 	if err != nil {
 		return nil, err
 	}
+	// check if the pdu is an exception returned by the server
+	if exception, ok := op.(*data.ModbusOperationException); ok {
+		return nil, exception.Exception(uint16(unitId))
+	}
 	pdu := transport.NewProtocolDataUnit(op)
 	adu := &modbusApplicationDataUnit{
 		header: NewHeader(txId, protoId, unitId),
@@ -131,8 +171,11 @@ func ParseModbusServerResponseFrame(packet []byte, valueCount int) (transport.Ap
 	return adu, nil
 }
 
-func NewModbusApplicationDataUnit(header transport.Header, response *transport.ProtocolDataUnit) transport.ApplicationDataUnit {
-	return &modbusApplicationDataUnit{header: header.(transport.NetworkHeader), pdu: response}
+func NewModbusApplicationDataUnit(header transport.Header, response *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
+	if _, err := response.Bytes(); err != nil {
+		return nil, err
+	}
+	return &modbusApplicationDataUnit{header: header.(transport.NetworkHeader), pdu: response}, nil
 }
 
 func NewFrameBuilder() transport.FrameBuilder {
@@ -143,7 +186,7 @@ type frameBuilder struct{}
 
 func (fb *frameBuilder) BuildResponseFrame(header transport.Header, response *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
 	if networkHeader, ok := header.(transport.NetworkHeader); ok {
-		return NewModbusApplicationDataUnit(networkHeader, response), nil
+		return NewModbusApplicationDataUnit(networkHeader, response)
 	}
 	return nil, fmt.Errorf("invalid header")
 }
@@ -3,6 +3,7 @@ package network
 import (
 	"testing"
 
+	"github.com/rinzlerlabs/gomodbus/common"
 	"github.com/rinzlerlabs/gomodbus/data"
 	"github.com/rinzlerlabs/gomodbus/transport"
 	"github.com/stretchr/testify/assert"
@@ -136,3 +137,46 @@ func TestADU_Bytes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseModbusServerResponseFrame_Exception(t *testing.T) {
+	_, err := ParseModbusServerResponseFrame([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x11, 0x83, 0x06}, 0)
+	var exception *data.ModbusException
+	assert.ErrorAs(t, err, &exception)
+	assert.Equal(t, data.ReadHoldingRegisters, exception.FunctionCode)
+	assert.Equal(t, data.ServerDeviceBusy, exception.ExceptionCode)
+	assert.Equal(t, uint16(0x11), exception.Address)
+	assert.ErrorIs(t, err, common.ErrServerDeviceBusy)
+}
+
+func TestParseModbusRequestFrame_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		packet        []byte
+		expectedError error
+	}{
+		{
+			name:          "TooShort",
+			packet:        []byte{0x00, 0x01, 0x00, 0x00, 0x00},
+			expectedError: common.ErrInvalidLength,
+		},
+		{
+			name:          "InvalidProtocolID",
+			packet:        []byte{0x00, 0x01, 0x12, 0x34, 0x00, 0x06, 0x01, 0x03, 0x00, 0x6B, 0x00, 0x03},
+			expectedError: common.ErrInvalidProtocolID,
+		},
+		{
+			name:          "LengthMismatch",
+			packet:        []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x08, 0x01, 0x03, 0x00, 0x6B, 0x00, 0x03},
+			expectedError: common.ErrInvalidLength,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseModbusRequestFrame(tt.packet)
+			assert.Equal(t, tt.expectedError, err)
+			_, err = ParseModbusServerResponseFrame(tt.packet, 0)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
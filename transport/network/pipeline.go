@@ -0,0 +1,135 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rinzlerlabs/gomodbus/common"
+	"github.com/rinzlerlabs/gomodbus/transport"
+	"go.uber.org/zap"
+)
+
+// pipelineResult is a response frame, or the error that stopped us reading it
+type pipelineResult struct {
+	frame []byte
+	err   error
+}
+
+// pipeline hands the responses read from the connection to the requests waiting for them, matched by transaction ID
+type pipeline struct {
+	transport *modbusTCPSocketTransport
+	mu        sync.Mutex
+	pending   map[uint16]chan pipelineResult
+	err       error
+	start     sync.Once
+}
+
+func newPipeline(t *modbusTCPSocketTransport) *pipeline {
+	return &pipeline{
+		transport: t,
+		pending:   make(map[uint16]chan pipelineResult),
+	}
+}
+
+func transactionID(adu transport.ApplicationDataUnit) uint16 {
+	id := adu.Header().(transport.NetworkHeader).TransactionID()
+	return uint16(id[0])<<8 | uint16(id[1])
+}
+
+// expect registers a request that is about to be written, starting the reader the first time
+func (p *pipeline) expect(request transport.ApplicationDataUnit) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.pending[transactionID(request)] = make(chan pipelineResult, 1)
+	p.start.Do(func() {
+		p.transport.wg.Add(1)
+		go p.run()
+	})
+	return nil
+}
+
+// forget stops waiting for the response to a request
+func (p *pipeline) forget(request transport.ApplicationDataUnit) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, transactionID(request))
+}
+
+func (p *pipeline) readResponse(ctx context.Context, request transport.ApplicationDataUnit) (transport.ApplicationDataUnit, error) {
+	p.mu.Lock()
+	result, ok := p.pending[transactionID(request)]
+	p.mu.Unlock()
+	if !ok {
+		return nil, common.ErrInvalidPacket
+	}
+	defer p.forget(request)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(p.transport.responseTimeout):
+		return nil, common.ErrTimeout
+	case r := <-result:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return p.transport.parseResponseFrame(request, r.frame)
+	}
+}
+
+// run reads responses until the connection fails, then fails every request still waiting
+func (p *pipeline) run() {
+	defer p.transport.wg.Done()
+	for {
+		frame, err := p.transport.readRawFrame()
+		if err != nil {
+			if (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)) && p.transport.closing {
+				err = errors.Join(err, common.ErrTransportClosing)
+			}
+			p.fail(err)
+			return
+		}
+		id := uint16(frame[0])<<8 | uint16(frame[1])
+		// The request stays pending until its caller picks up the response, it may not have started waiting yet
+		p.mu.Lock()
+		result, ok := p.pending[id]
+		p.mu.Unlock()
+		if !ok {
+			// Most likely the response to a request that already timed out
+			p.transport.logger.Debug("Dropping response nobody is waiting for", zap.Uint16("transactionID", id))
+			continue
+		}
+		select {
+		case result <- pipelineResult{frame: frame}:
+		default:
+			p.transport.logger.Warn("Dropping duplicate response", zap.Uint16("transactionID", id))
+		}
+	}
+}
+
+// fail closes the connection, since nothing more can be read from it, and fails every request still waiting
+func (p *pipeline) fail(err error) {
+	if !p.transport.closing {
+		// After a bad frame we can't find the start of the next one, closing the connection makes every later request
+		// fail with net.ErrClosed so whoever owns it knows to reconnect
+		_ = p.transport.conn.Close()
+		err = errors.Join(err, net.ErrClosed)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.transport.logger.Debug("Pipeline stopped reading responses", zap.Error(err))
+	p.err = err
+	for _, result := range p.pending {
+		select {
+		case result <- pipelineResult{err: err}:
+		default:
+		}
+	}
+}
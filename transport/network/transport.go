@@ -1,6 +1,7 @@
 package network
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"io"
@@ -22,18 +23,23 @@ type ReadWriteCloseRemoteAddresser interface {
 type modbusTCPSocketTransport struct {
 	logger          *zap.Logger
 	mu              sync.Mutex
+	readMu          sync.Mutex
 	conn            ReadWriteCloseRemoteAddresser
+	reader          *bufio.Reader
 	frameBuilder    transport.FrameBuilder
 	headerManager   *headerManager
 	responseTimeout time.Duration
 	closing         bool
 	wg              sync.WaitGroup
+	maxInFlight     int
+	pipeline        *pipeline
 }
 
 func NewModbusServerTransport(conn ReadWriteCloseRemoteAddresser, logger *zap.Logger) transport.Transport {
 	return &modbusTCPSocketTransport{
 		logger:        logger,
 		conn:          conn,
+		reader:        bufio.NewReader(conn),
 		frameBuilder:  NewFrameBuilder(),
 		headerManager: &headerManager{},
 	}
@@ -43,22 +49,60 @@ func NewModbusClientTransport(conn ReadWriteCloseRemoteAddresser, logger *zap.Lo
 	return &modbusTCPSocketTransport{
 		logger:          logger,
 		conn:            conn,
+		reader:          bufio.NewReader(conn),
 		frameBuilder:    NewFrameBuilder(),
 		headerManager:   &headerManager{},
 		responseTimeout: responseTimeout,
+		maxInFlight:     1,
 	}
 }
 
+// NewModbusPipelinedClientTransport creates a client transport that allows up to maxInFlight requests to be outstanding
+// on the connection at once. A reader goroutine matches the responses to their requests by transaction ID, so they can
+// arrive in any order.
+func NewModbusPipelinedClientTransport(conn ReadWriteCloseRemoteAddresser, logger *zap.Logger, responseTimeout time.Duration, maxInFlight int) transport.Transport {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	t := &modbusTCPSocketTransport{
+		logger:          logger,
+		conn:            conn,
+		reader:          bufio.NewReader(conn),
+		frameBuilder:    NewFrameBuilder(),
+		headerManager:   &headerManager{},
+		responseTimeout: responseTimeout,
+		maxInFlight:     maxInFlight,
+	}
+	t.pipeline = newPipeline(t)
+	return t
+}
+
+// MaxInFlight returns the number of requests that can be outstanding on the connection at once.
+func (m *modbusTCPSocketTransport) MaxInFlight() int {
+	return m.maxInFlight
+}
+
+// readRawFrame reads a single frame from the stream, TCP doesn't keep message boundaries so a frame can arrive in pieces
+// or together with the next one, the MBAP header tells us how much to read.
 func (m *modbusTCPSocketTransport) readRawFrame() ([]byte, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.readMu.Lock()
+	defer m.readMu.Unlock()
 	m.logger.Debug("Reading data from TCP socket")
-	data := make([]byte, 260)
-	n, err := m.conn.Read(data)
-	if err != nil {
+	data := make([]byte, mbapHeaderLength, maxFrameLength)
+	if _, err := io.ReadFull(m.reader, data); err != nil {
+		return nil, err
+	}
+	if err := validateMBAPHeader(data); err != nil {
+		// Once the header is wrong we can't find the start of the next frame, so the connection is no good
+		m.logger.Warn("Received an invalid MBAP header", zap.String("header", common.EncodeToString(data)), zap.Error(err))
+		return nil, errors.Join(common.ErrInvalidHeader, err)
+	}
+	// The length includes the unit ID, which we already read as part of the header
+	length := int(data[4])<<8 | int(data[5])
+	data = data[:mbapHeaderLength+length-1]
+	if _, err := io.ReadFull(m.reader, data[mbapHeaderLength:]); err != nil {
 		return nil, err
 	}
-	data = data[:n]
 	m.logger.Debug("Received data from TCP socket", zap.String("data", common.EncodeToString(data)))
 	return data, nil
 }
@@ -71,7 +115,7 @@ func (m *modbusTCPSocketTransport) ReadRequest(ctx context.Context) (transport.A
 	go func() {
 		defer m.wg.Done()
 		data, err := m.readRequestFrame()
-		if (errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)) && m.closing {
+		if (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)) && m.closing {
 			m.logger.Debug("Socket read error while transport is closing")
 			errChan <- errors.Join(err, common.ErrTransportClosing)
 			return
@@ -94,12 +138,15 @@ func (m *modbusTCPSocketTransport) ReadRequest(ctx context.Context) (transport.A
 }
 
 func (m *modbusTCPSocketTransport) ReadResponse(ctx context.Context, request transport.ApplicationDataUnit) (transport.ApplicationDataUnit, error) {
+	if m.pipeline != nil {
+		return m.pipeline.readResponse(ctx, request)
+	}
 	dataChan := make(chan transport.ApplicationDataUnit, 1)
 	errChan := make(chan error, 1)
 
 	go func() {
 		data, err := m.readResponseFrame(request)
-		if (errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)) && m.closing {
+		if (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)) && m.closing {
 			m.logger.Debug("Socket read error while transport is closing")
 			errChan <- errors.Join(err, common.ErrTransportClosing)
 			return
@@ -136,13 +183,26 @@ func (m *modbusTCPSocketTransport) Flush(context.Context) error {
 }
 
 func (m *modbusTCPSocketTransport) WriteRequestFrame(address uint16, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
-	header := m.headerManager.NewHeader()
+	// The address is sent as the unit ID, which is only a byte wide
+	if address > 0xFF {
+		return nil, common.ErrInvalidAddress
+	}
+	header := m.headerManager.NewHeader(byte(address))
 	adu, err := m.frameBuilder.BuildResponseFrame(header, pdu)
 	if err != nil {
 		return nil, err
 	}
+	if m.pipeline != nil {
+		// The response can come back as soon as the request is written, so we have to be waiting for it first
+		if err := m.pipeline.expect(adu); err != nil {
+			return nil, err
+		}
+	}
 	_, err = m.write(adu.Bytes())
 	if err != nil {
+		if m.pipeline != nil {
+			m.pipeline.forget(adu)
+		}
 		return nil, err
 	}
 	return adu, nil
@@ -184,6 +244,15 @@ func (t *modbusTCPSocketTransport) readResponseFrame(request transport.Applicati
 	if err != nil {
 		return nil, err
 	}
+	return t.parseResponseFrame(request, bytes)
+}
+
+func (t *modbusTCPSocketTransport) parseResponseFrame(request transport.ApplicationDataUnit, bytes []byte) (transport.ApplicationDataUnit, error) {
+	// A gateway answers for many devices, so make sure this response came from the one we asked
+	if unitID := request.Header().(transport.NetworkHeader).UnitID(); bytes[6] != unitID {
+		t.logger.Warn("Response unit ID does not match the request", zap.Uint8("expected", unitID), zap.Uint8("actual", bytes[6]))
+		return nil, common.ErrResponseUnitIDMismatch
+	}
 	if op, ok := request.PDU().Operation().(data.CountableOperation); ok {
 		return ParseModbusServerResponseFrame(bytes, op.Count())
 	}
@@ -195,10 +264,10 @@ type headerManager struct {
 	transactionID uint16
 }
 
-func (hm *headerManager) NewHeader() transport.Header {
+func (hm *headerManager) NewHeader(unitID byte) transport.Header {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
 	hm.transactionID++
 	txnId := []byte{byte(hm.transactionID >> 8), byte(hm.transactionID & 0xff)}
-	return NewHeader(txnId, []byte{0x00, 0x00}, byte(0x01))
+	return NewHeader(txnId, []byte{0x00, 0x00}, unitID)
 }
@@ -28,6 +28,8 @@ type testConnection struct {
 	readData  []byte
 	writeData []byte
 	closeChan chan struct{}
+	// readSize limits how much a single Read returns, so frames arrive in pieces
+	readSize int
 }
 
 func (t *testConnection) Read(b []byte) (n int, err error) {
@@ -37,6 +39,9 @@ func (t *testConnection) Read(b []byte) (n int, err error) {
 	if len(t.readData) == 0 {
 		return 0, io.EOF
 	}
+	if t.readSize > 0 && len(b) > t.readSize {
+		b = b[:t.readSize]
+	}
 	lenRead := copy(b, t.readData)
 	t.readData = t.readData[lenRead:]
 	return lenRead, nil
@@ -79,7 +84,9 @@ func TestAcceptRequest(t *testing.T) {
 	assert.Equal(t, []byte{0x00, 0x00}, txn.Header().(transport.NetworkHeader).ProtocolID())
 	assert.Equal(t, byte(0x01), txn.Header().(transport.NetworkHeader).UnitID())
 	assert.Equal(t, data.FunctionCode(0x01), txn.PDU().FunctionCode())
-	assert.Equal(t, []byte{0x00, 0x0A, 0x00, 0x0D}, data.ModbusOperationToBytes(txn.PDU().Operation()))
+	opBytes, err := data.ModbusOperationToBytes(txn.PDU().Operation())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x0A, 0x00, 0x0D}, opBytes)
 	assert.Equal(t, []byte{}, []byte(txn.Checksum()))
 }
 
@@ -463,3 +470,212 @@ func TestRaceOnReadAndClose(t *testing.T) {
 	assert.NoError(t, err)
 	wg.Wait()
 }
+
+func TestClientUnitID(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tests := []struct {
+		name      string
+		address   uint16
+		response  string
+		request   string
+		values    []uint16
+		readError error
+	}{
+		{
+			name:     "Valid",
+			address:  0x11,
+			request:  "000100000006110300000002",
+			response: "000100000007110304000A000B",
+			values:   []uint16{0x000A, 0x000B},
+		},
+		{
+			name:      "UnitIDMismatch",
+			address:   0x11,
+			request:   "000100000006110300000002",
+			response:  "000100000007120304000A000B",
+			readError: common.ErrResponseUnitIDMismatch,
+		},
+		{
+			name:      "AddressTooLarge",
+			address:   0x0100,
+			readError: common.ErrInvalidAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			d, err := hex.DecodeString(tt.response)
+			assert.NoError(t, err)
+			port := newTestConnection(d)
+			tp := NewModbusClientTransport(port, logger, 1*time.Second)
+			defer tp.Close()
+			var resp transport.ApplicationDataUnit
+			req, err := tp.WriteRequestFrame(tt.address, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(0, 2)))
+			if err == nil {
+				assert.Equal(t, tt.request, hex.EncodeToString(port.writeData))
+				resp, err = tp.ReadResponse(ctx, req)
+			}
+			if tt.readError != nil {
+				assert.ErrorIs(t, err, tt.readError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.values, resp.PDU().Operation().(*data.ReadHoldingRegistersResponse).Values())
+		})
+	}
+}
+
+func TestReadRequestFraming(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tests := []struct {
+		name      string
+		stream    string
+		readSize  int
+		requests  int
+		readError error
+	}{
+		{
+			name:     "SplitAcrossReads",
+			stream:   "0002000000060103006B0003",
+			readSize: 3,
+			requests: 1,
+		},
+		{
+			name:     "TwoFramesInOneRead",
+			stream:   "0002000000060103006B00030003000000060103006C0001",
+			requests: 2,
+		},
+		{
+			name:      "InvalidProtocolID",
+			stream:    "0002000100060103006B0003",
+			readError: common.ErrInvalidProtocolID,
+		},
+		{
+			name:      "LengthTooShort",
+			stream:    "0002000000010103006B0003",
+			readError: common.ErrInvalidLength,
+		},
+		{
+			name:      "LengthTooLong",
+			stream:    "0002000000FF0103006B0003",
+			readError: common.ErrInvalidLength,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			d, err := hex.DecodeString(tt.stream)
+			assert.NoError(t, err)
+			port := newTestConnection(d)
+			port.readSize = tt.readSize
+			tp := NewModbusServerTransport(port, logger)
+			defer tp.Close()
+			if tt.readError != nil {
+				_, err := tp.ReadRequest(ctx)
+				assert.ErrorIs(t, err, tt.readError)
+				assert.ErrorIs(t, err, common.ErrInvalidHeader)
+				return
+			}
+			for i := 0; i < tt.requests; i++ {
+				txn, err := tp.ReadRequest(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte{0x00, byte(0x02 + i)}, txn.Header().(transport.NetworkHeader).TransactionID())
+				assert.Equal(t, uint16(0x006B+i), txn.PDU().Operation().(*data.ReadHoldingRegistersRequest).Offset())
+			}
+		})
+	}
+}
+
+func TestPipelinedResponsesOutOfOrder(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	tp := NewModbusPipelinedClientTransport(clientConn, logger, 1*time.Second, 3)
+	defer tp.Close()
+	assert.Equal(t, 3, tp.(transport.PipelinedTransport).MaxInFlight())
+
+	// The server reads all three requests, then answers them newest first with the requested offset as the value
+	go func() {
+		requests := make([][]byte, 3)
+		for i := range requests {
+			requests[i] = make([]byte, 12)
+			if _, err := io.ReadFull(serverConn, requests[i]); err != nil {
+				return
+			}
+		}
+		for i := len(requests) - 1; i >= 0; i-- {
+			r := requests[i]
+			response := []byte{r[0], r[1], 0x00, 0x00, 0x00, 0x05, r[6], 0x03, 0x02, r[8], r[9]}
+			if _, err := serverConn.Write(response); err != nil {
+				return
+			}
+		}
+	}()
+
+	requests := make([]transport.ApplicationDataUnit, 3)
+	for i := range requests {
+		req, err := tp.WriteRequestFrame(0x01, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(uint16(i), 1)))
+		assert.NoError(t, err)
+		requests[i] = req
+	}
+	for i, req := range requests {
+		resp, err := tp.ReadResponse(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint16{uint16(i)}, resp.PDU().Operation().(*data.ReadHoldingRegistersResponse).Values())
+	}
+}
+
+func TestPipelinedConnectionClosed(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	clientConn, serverConn := net.Pipe()
+	tp := NewModbusPipelinedClientTransport(clientConn, logger, 1*time.Second, 2)
+	defer tp.Close()
+
+	go func() {
+		request := make([]byte, 12)
+		_, _ = io.ReadFull(serverConn, request)
+		serverConn.Close()
+	}()
+
+	req, err := tp.WriteRequestFrame(0x01, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(0, 1)))
+	assert.NoError(t, err)
+	_, err = tp.ReadResponse(ctx, req)
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = tp.WriteRequestFrame(0x01, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(0, 1)))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPipelinedInvalidHeader(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	tp := NewModbusPipelinedClientTransport(clientConn, logger, 1*time.Second, 2)
+	defer tp.Close()
+
+	closed := make(chan error, 1)
+	go func() {
+		request := make([]byte, 12)
+		if _, err := io.ReadFull(serverConn, request); err != nil {
+			return
+		}
+		// The protocol ID isn't 0, so the client loses track of where the frames start
+		_, _ = serverConn.Write([]byte{request[0], request[1], 0x00, 0x01, 0x00, 0x05, request[6], 0x03, 0x02, 0x00, 0x00})
+		_, err := serverConn.Read(request)
+		closed <- err
+	}()
+
+	req, err := tp.WriteRequestFrame(0x01, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(0, 1)))
+	assert.NoError(t, err)
+	_, err = tp.ReadResponse(ctx, req)
+	assert.ErrorIs(t, err, common.ErrInvalidProtocolID)
+	assert.ErrorIs(t, err, net.ErrClosed)
+	_, err = tp.WriteRequestFrame(0x01, transport.NewProtocolDataUnit(data.NewReadHoldingRegistersRequest(0, 1)))
+	assert.ErrorIs(t, err, net.ErrClosed)
+	// The client closed its end of the connection
+	assert.ErrorIs(t, <-closed, io.EOF)
+}
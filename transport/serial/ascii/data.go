@@ -11,7 +11,7 @@ import (
 
 func NewModbusApplicationDataUnit(header transport.Header, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
 	if serialHeader, ok := header.(transport.SerialHeader); ok {
-		return serial.NewResponseModbusApplicationDataUnit(serialHeader, pdu, checksummer), nil
+		return serial.NewResponseModbusApplicationDataUnit(serialHeader, pdu, checksummer)
 	}
 	return nil, common.ErrInvalidHeader
 }
@@ -22,7 +22,8 @@ func checksummer(m transport.ApplicationDataUnit) transport.ErrorCheck {
 	lrc += byte(m.Header().Bytes()[0])
 	// then the data
 	// TODO: Avoid the byte array allocation
-	bytes := m.PDU().Bytes()
+	// The operation was checked when the ADU was created
+	bytes, _ := m.PDU().Bytes()
 	for _, b := range bytes {
 		lrc += b
 	}
@@ -66,7 +67,7 @@ func ParseModbusResponseFrame(packet []byte, valueCount int) (transport.Applicat
 	}
 	// check if the pdu is an exception returned by the server
 	if pdu.FunctionCode().IsException() {
-		return nil, pdu.Operation().(*data.ModbusOperationException).Error()
+		return nil, pdu.Operation().(*data.ModbusOperationException).Exception(uint16(packet[0]))
 	}
 	return adu, nil
 }
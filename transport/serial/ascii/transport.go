@@ -23,6 +23,7 @@ type modbusASCIITransport struct {
 	reader          *bufio.Reader
 	frameBuilder    transport.FrameBuilder
 	responseTimeout time.Duration
+	turnaroundDelay time.Duration
 	closing         bool
 }
 
@@ -36,15 +37,26 @@ func NewModbusServerTransport(stream io.ReadWriteCloser, logger *zap.Logger) tra
 }
 
 func NewModbusClientTransport(stream io.ReadWriteCloser, logger *zap.Logger, responseTimeout time.Duration) transport.Transport {
+	return NewModbusClientTransportWithTurnaroundDelay(stream, logger, responseTimeout, serial.DefaultTurnaroundDelay)
+}
+
+// NewModbusClientTransportWithTurnaroundDelay creates a client transport that waits turnaroundDelay after every broadcast
+// before sending the next request.
+func NewModbusClientTransportWithTurnaroundDelay(stream io.ReadWriteCloser, logger *zap.Logger, responseTimeout, turnaroundDelay time.Duration) transport.Transport {
 	return &modbusASCIITransport{
 		logger:          logger,
 		stream:          stream,
 		reader:          bufio.NewReader(stream),
 		frameBuilder:    serial.NewFrameBuilder(NewModbusApplicationDataUnit),
 		responseTimeout: responseTimeout,
+		turnaroundDelay: turnaroundDelay,
 	}
 }
 
+func (t *modbusASCIITransport) TurnaroundDelay() time.Duration {
+	return t.turnaroundDelay
+}
+
 func (t *modbusASCIITransport) readRawFrame(context.Context) ([]byte, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -59,8 +71,8 @@ func (t *modbusASCIITransport) readRawFrame(context.Context) ([]byte, error) {
 }
 
 func (t *modbusASCIITransport) ReadResponse(ctx context.Context, request transport.ApplicationDataUnit) (transport.ApplicationDataUnit, error) {
-	bytesChan := make(chan []byte)
-	errChan := make(chan error)
+	bytesChan := make(chan []byte, 1)
+	errChan := make(chan error, 1)
 
 	go func() {
 		bytes, err := t.readRawFrame(ctx)
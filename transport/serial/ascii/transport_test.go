@@ -61,7 +61,9 @@ func TestAcceptRequest(t *testing.T) {
 	assert.NotNil(t, txn)
 	assert.Equal(t, uint16(0x02), txn.Header().(transport.SerialHeader).Address())
 	assert.Equal(t, data.FunctionCode(0x01), txn.PDU().FunctionCode())
-	assert.Equal(t, []byte{0x00, 0x20, 0x00, 0xC}, data.ModbusOperationToBytes(txn.PDU().Operation()))
+	opBytes, err := data.ModbusOperationToBytes(txn.PDU().Operation())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x20, 0x00, 0xC}, opBytes)
 	assert.Equal(t, []byte{0xD1}, []byte(txn.Checksum()))
 }
 
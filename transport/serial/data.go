@@ -2,12 +2,20 @@ package serial
 
 import (
 	"sync"
+	"time"
 
 	"github.com/rinzlerlabs/gomodbus/common"
 	"github.com/rinzlerlabs/gomodbus/transport"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// BroadcastAddress is the address of a request that every device carries out without answering.
+	BroadcastAddress = 0
+	// DefaultTurnaroundDelay is how long a client waits after a broadcast before sending the next request.
+	DefaultTurnaroundDelay = 100 * time.Millisecond
+)
+
 func NewHeader(address uint16) *header {
 	return &header{address: address}
 }
@@ -44,12 +52,16 @@ func (fb *FrameBuilder) BuildResponseFrame(header transport.Header, response *tr
 
 type checksummer func(transport.ApplicationDataUnit) transport.ErrorCheck
 
-func NewResponseModbusApplicationDataUnit(header transport.SerialHeader, pdu *transport.ProtocolDataUnit, checksummer checksummer) transport.ApplicationDataUnit {
+func NewResponseModbusApplicationDataUnit(header transport.SerialHeader, pdu *transport.ProtocolDataUnit, checksummer checksummer) (transport.ApplicationDataUnit, error) {
+	// The checksum and the bytes are worked out when they are needed, so the operation is checked here
+	if _, err := pdu.Bytes(); err != nil {
+		return nil, err
+	}
 	return &modbusApplicationDataUnit{
 		header:      header,
 		pdu:         pdu,
 		checksummer: checksummer,
-	}
+	}, nil
 }
 
 func NewModbusApplicationDataUnit(header transport.SerialHeader, pdu *transport.ProtocolDataUnit, checksum transport.ErrorCheck, checksummer checksummer) (transport.ApplicationDataUnit, error) {
@@ -107,5 +119,7 @@ func (m *modbusApplicationDataUnit) Checksum() transport.ErrorCheck {
 }
 
 func (m *modbusApplicationDataUnit) Bytes() []byte {
-	return append(append(m.header.Bytes(), m.pdu.Bytes()...), m.Checksum()...)
+	// The operation was checked when the ADU was created
+	pduBytes, _ := m.pdu.Bytes()
+	return append(append(m.header.Bytes(), pduBytes...), m.Checksum()...)
 }
@@ -9,18 +9,24 @@ import (
 
 func NewModbusApplicationDataUnit(header transport.Header, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
 	if serialHeader, ok := header.(transport.SerialHeader); ok {
-		return serial.NewResponseModbusApplicationDataUnit(serialHeader, pdu, checksummer), nil
+		return serial.NewResponseModbusApplicationDataUnit(serialHeader, pdu, checksummer)
 	}
 	return nil, common.ErrInvalidHeader
 }
 
 func checksummer(m transport.ApplicationDataUnit) transport.ErrorCheck {
-	var crc uint16 = 0xFFFF
 	// TODO: avoid the byte array allocation
+	// The operation was checked when the ADU was created
+	pduBytes, _ := m.PDU().Bytes()
 	bytes := make([]byte, 0)
 	bytes = append(bytes, m.Header().Bytes()...)
-	bytes = append(bytes, byte(m.PDU().FunctionCode()))
-	bytes = append(bytes, data.ModbusOperationToBytes(m.PDU().Operation())...)
+	bytes = append(bytes, pduBytes...)
+	return crc(bytes)
+}
+
+// crc is the CRC-16 of a frame's address, function code and data, low byte first as it is sent
+func crc(bytes []byte) transport.ErrorCheck {
+	var crc uint16 = 0xFFFF
 	for _, b := range bytes {
 		crc ^= uint16(b)
 		for i := 0; i < 8; i++ {
@@ -60,7 +66,7 @@ func ParseModbusResponseFrame(packet []byte, valueCount int) (transport.Applicat
 		return nil, err
 	}
 	if pdu.FunctionCode().IsException() {
-		return nil, pdu.Operation().(*data.ModbusOperationException).Error()
+		return nil, pdu.Operation().(*data.ModbusOperationException).Exception(uint16(packet[0]))
 	}
 	return adu, nil
 }
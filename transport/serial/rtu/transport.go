@@ -24,7 +24,10 @@ type modbusRTUTransport struct {
 	stream          io.ReadWriteCloser
 	reader          *bufio.Reader
 	serverAddr      uint16
+	accepts         func(address uint16) bool
 	responseTimeout time.Duration
+	turnaroundDelay time.Duration
+	client          bool
 	closing         bool
 	wg              sync.WaitGroup
 }
@@ -41,18 +44,45 @@ func NewModbusServerTransport(stream io.ReadWriteCloser, logger *zap.Logger, ser
 }
 
 func NewModbusClientTransport(stream io.ReadWriteCloser, logger *zap.Logger, responseTimeout time.Duration) transport.Transport {
+	return NewModbusClientTransportWithTurnaroundDelay(stream, logger, responseTimeout, serial.DefaultTurnaroundDelay)
+}
+
+// NewModbusClientTransportWithTurnaroundDelay creates a client transport that waits turnaroundDelay after every broadcast
+// before sending the next request.
+func NewModbusClientTransportWithTurnaroundDelay(stream io.ReadWriteCloser, logger *zap.Logger, responseTimeout, turnaroundDelay time.Duration) transport.Transport {
 	return &modbusRTUTransport{
 		logger:          logger,
 		stream:          stream,
 		frameBuilder:    serial.NewFrameBuilder(NewModbusApplicationDataUnit),
 		reader:          bufio.NewReader(stream),
 		responseTimeout: responseTimeout,
+		turnaroundDelay: turnaroundDelay,
+		client:          true,
+	}
+}
+
+// SetAddressFilter replaces the server address given to NewModbusServerTransport, it must be called before the first
+// request is read.
+func (t *modbusRTUTransport) SetAddressFilter(accepts func(address uint16) bool) {
+	t.accepts = accepts
+}
+
+func (t *modbusRTUTransport) acceptsAddress(address uint16) bool {
+	if t.accepts != nil {
+		return t.accepts(address)
 	}
+	return address == t.serverAddr || address == serial.BroadcastAddress
+}
+
+func (t *modbusRTUTransport) TurnaroundDelay() time.Duration {
+	return t.turnaroundDelay
 }
 
 func (t *modbusRTUTransport) readWithTimeout(ctx context.Context, timeout time.Duration, bytes []byte, pos int) (int, error) {
 	dataChan := make(chan int, 1)
 	errChan := make(chan error, 1)
+	// Close drops the stream while this goroutine may still be running, so hold on to our own reference
+	stream := t.stream
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
@@ -64,7 +94,7 @@ func (t *modbusRTUTransport) readWithTimeout(ctx context.Context, timeout time.D
 				errChan <- ctx.Err()
 				return
 			default:
-				n, err := t.stream.Read(d)
+				n, err := stream.Read(d)
 				if err == syscall.EWOULDBLOCK {
 					continue
 				}
@@ -110,16 +140,13 @@ start:
 		t.logger.Warn("Failed to read header bytes", zap.Error(err))
 		return nil, err
 	}
-	// This is a bit of a cheat, basically, if the first byte we read isn't our address, it is almost certainly not the start of a packet
-	// If this check fails, the default case on the function code switch will discard the packet
-	if bytes[0] != byte(t.serverAddr) {
-		goto start
-	}
+	// Frames for other addresses are still read to the end, so we stay in step with the bus and it can count them
+	ours := t.acceptsAddress(uint16(bytes[0]))
 
 	functionCode := data.FunctionCode(bytes[1])
 	switch functionCode {
-	case data.ReadCoils, data.ReadDiscreteInputs, data.ReadHoldingRegisters, data.ReadInputRegisters, data.WriteSingleCoil, data.WriteSingleRegister:
-		// All of these functions are exactly 8 bytes long
+	case data.ReadCoils, data.ReadDiscreteInputs, data.ReadHoldingRegisters, data.ReadInputRegisters, data.WriteSingleCoil, data.WriteSingleRegister, data.Diagnostics:
+		// All of these functions are exactly 8 bytes long, Diagnostics is assumed to carry a single data word on serial lines
 		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:8], read)
 		if err != nil {
 			t.logger.Warn("Failed to read body bytes", zap.Error(err))
@@ -161,12 +188,107 @@ start:
 			t.logger.Warn("Failed to read body bytes", zap.Error(err))
 			return nil, err
 		}
+	case data.MaskWriteRegister:
+		// This function is exactly 10 bytes long
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:10], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadExceptionStatus, data.GetCommEventCounter, data.GetCommEventLog, data.ReportServerID:
+		// These functions have no data, so they are just the address, function code and CRC
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:4], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadFileRecord, data.WriteFileRecord:
+		// These functions have a variable number of sub-requests, the byte count is the 3rd byte
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:3], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+		byteCount := int(bytes[2])
+		// Every sub-request is at least 7 bytes and the whole PDU has to fit in 253 bytes
+		if byteCount < 0x07 || byteCount > 0xF5 {
+			t.logger.Warn("Invalid byte count for file record request, this usually indicates a corrupt packet", zap.Int("byteCount", byteCount))
+			goto start
+		}
+		// 1 for address, 1 for function code, 1 for byte count, then 2 more for the CRC
+		bytesNeeded := byteCount + 3 + 2
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:bytesNeeded], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadFIFOQueue:
+		// This function is exactly 6 bytes long
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:6], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.EncapsulatedInterfaceTransport:
+		// Read Device Identification requests are exactly 7 bytes long
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:7], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadWriteMultipleRegisters:
+		// This function has a variable length, the byte count for the write values is the 11th byte
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:11], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+		byteCount := int(bytes[10])
+		writeCount := uint16(bytes[8])<<8 | uint16(bytes[9])
+		// At most 121 registers can be written, more than that doesn't fit in a frame
+		if writeCount < 1 || writeCount > 0x79 {
+			t.logger.Warn("Invalid write quantity for ReadWriteMultipleRegisters, this usually indicates a corrupt packet", zap.Uint16("writeCount", writeCount))
+			goto start
+		}
+		// The byte count must be twice the write register count
+		if byteCount != int(writeCount*2) {
+			t.logger.Warn("Invalid byte count for ReadWriteMultipleRegisters, this usually indicates a corrupt packet", zap.Int("byteCount", byteCount))
+			goto start
+		}
+		// 1 for address, 1 for function code, 2 for read starting address, 2 for read quantity, 2 for write starting address,
+		// 2 for write quantity, 1 for byte count, which is 11 bytes, next add 2 more for the CRC
+		bytesNeeded := byteCount + 11 + 2
+		if bytesNeeded > len(bytes) {
+			t.logger.Warn("Request indicates it needs more than 256 bytes, this is likely a corrupt packet", zap.Int("bytesNeeded", bytesNeeded))
+			goto start
+		}
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:bytesNeeded], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
 	default:
-		// This likely means we have a timing error, so we discard the packet
-		t.logger.Debug("Unsupported function code", zap.Uint8("functionCode", uint8(functionCode)))
-		goto start
+		custom, ok := data.LookupCustomFunction(functionCode)
+		if !ok || custom.RequestLength == nil {
+			// This likely means we have a timing error, so we discard the packet
+			t.logger.Debug("Unsupported function code", zap.Uint8("functionCode", uint8(functionCode)))
+			goto start
+		}
+		read, err = t.readCustomFrame(ctx, bytes, read, custom.RequestLength)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
 	}
 	t.logger.Debug("Raw Frame", zap.String("bytes", common.EncodeToString(bytes[:read])))
+	if !ours {
+		// If the CRC doesn't match, the first byte almost certainly wasn't the start of a frame, so we discard it
+		checksum := crc(bytes[:read-2])
+		if checksum[0] != bytes[read-2] || checksum[1] != bytes[read-1] {
+			goto start
+		}
+		return nil, common.ErrNotOurAddress
+	}
 	return ParseModbusRequestFrame(bytes[:read])
 }
 
@@ -183,7 +305,7 @@ func (t *modbusRTUTransport) ReadResponse(ctx context.Context, request transport
 	}
 	functionCode := data.FunctionCode(bytes[1])
 	switch functionCode {
-	case data.ReadCoils, data.ReadDiscreteInputs, data.ReadHoldingRegisters, data.ReadInputRegisters:
+	case data.ReadCoils, data.ReadDiscreteInputs, data.ReadHoldingRegisters, data.ReadInputRegisters, data.ReadWriteMultipleRegisters, data.GetCommEventLog, data.ReportServerID, data.ReadFileRecord, data.WriteFileRecord:
 		// These functions have a variable length, so we need to read the length byte
 		// The length byte is the 3rd byte
 		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:read+1], read)
@@ -204,14 +326,88 @@ func (t *modbusRTUTransport) ReadResponse(ctx context.Context, request transport
 			t.logger.Warn("Failed to read body bytes", zap.Error(err))
 			return nil, err
 		}
-	case data.WriteSingleCoil, data.WriteSingleRegister, data.WriteMultipleCoils, data.WriteMultipleRegisters:
+	case data.WriteSingleCoil, data.WriteSingleRegister, data.WriteMultipleCoils, data.WriteMultipleRegisters, data.Diagnostics, data.GetCommEventCounter:
 		// These functions are exactly 8 bytes long
 		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:8], read)
 		if err != nil {
 			t.logger.Warn("Failed to read body bytes", zap.Error(err))
 			return nil, err
 		}
-	case data.ReadCoilsError, data.ReadDiscreteInputsError, data.ReadHoldingRegistersError, data.ReadInputRegistersError, data.WriteSingleCoilError, data.WriteSingleRegisterError, data.WriteMultipleCoilsError, data.WriteMultipleRegistersError:
+	case data.MaskWriteRegister:
+		// This function is exactly 10 bytes long
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:10], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.EncapsulatedInterfaceTransport:
+		// Read Device Identification responses have a variable number of variable length objects, so we read the
+		// header up to the number of objects, then each object header and value, then the CRC
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:8], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+		objectCount := int(bytes[7])
+		for i := 0; i < objectCount; i++ {
+			if read+2 > len(bytes) {
+				t.logger.Warn("Response indicates it needs more than 256 bytes, this is likely a corrupt packet", zap.String("bytes", common.EncodeToString(bytes[:read])))
+				return nil, common.ErrInvalidPacket
+			}
+			read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:read+2], read)
+			if err != nil {
+				t.logger.Warn("Failed to read body bytes", zap.Error(err))
+				return nil, err
+			}
+			objectLength := int(bytes[read-1])
+			if read+objectLength > len(bytes) {
+				t.logger.Warn("Response indicates it needs more than 256 bytes, this is likely a corrupt packet", zap.String("bytes", common.EncodeToString(bytes[:read])))
+				return nil, common.ErrInvalidPacket
+			}
+			if objectLength == 0 {
+				continue
+			}
+			read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:read+objectLength], read)
+			if err != nil {
+				t.logger.Warn("Failed to read body bytes", zap.Error(err))
+				return nil, err
+			}
+		}
+		if read+2 > len(bytes) {
+			t.logger.Warn("Response indicates it needs more than 256 bytes, this is likely a corrupt packet", zap.String("bytes", common.EncodeToString(bytes[:read])))
+			return nil, common.ErrInvalidPacket
+		}
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:read+2], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadFIFOQueue:
+		// The byte count is 2 bytes wide, so we read up to it and then the rest of the values and the CRC
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:4], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+		byteCount := int(bytes[2])<<8 | int(bytes[3])
+		bytesNeeded := byteCount + 4 + 2
+		if bytesNeeded > 256 || byteCount < 2 {
+			t.logger.Warn("Response has an invalid byte count, this is likely a corrupt packet", zap.Int("byteCount", byteCount), zap.String("bytes", common.EncodeToString(bytes[:read])))
+			return nil, common.ErrInvalidPacket
+		}
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:bytesNeeded], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadExceptionStatus:
+		// This function is exactly 5 bytes long
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:5], read)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
+	case data.ReadCoilsError, data.ReadDiscreteInputsError, data.ReadHoldingRegistersError, data.ReadInputRegistersError, data.WriteSingleCoilError, data.WriteSingleRegisterError, data.ReadExceptionStatusError, data.DiagnosticsError, data.GetCommEventCounterError, data.GetCommEventLogError, data.ReportServerIDError, data.ReadFileRecordError, data.WriteFileRecordError, data.WriteMultipleCoilsError, data.WriteMultipleRegistersError, data.MaskWriteRegisterError, data.ReadWriteMultipleRegistersError, data.ReadFIFOQueueError, data.EncapsulatedInterfaceTransportError:
 		// These functions are exactly 5 bytes long
 		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:5], read)
 		if err != nil {
@@ -219,7 +415,27 @@ func (t *modbusRTUTransport) ReadResponse(ctx context.Context, request transport
 			return nil, err
 		}
 	default:
-		return nil, common.ErrUnsupportedFunctionCode
+		if functionCode.IsException() {
+			if _, ok := data.LookupCustomFunction(functionCode - 0x80); !ok {
+				return nil, common.ErrUnsupportedFunctionCode
+			}
+			// Exceptions are exactly 5 bytes long, even for custom functions
+			read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:5], read)
+			if err != nil {
+				t.logger.Warn("Failed to read body bytes", zap.Error(err))
+				return nil, err
+			}
+			break
+		}
+		custom, ok := data.LookupCustomFunction(functionCode)
+		if !ok || custom.ResponseLength == nil {
+			return nil, common.ErrUnsupportedFunctionCode
+		}
+		read, err = t.readCustomFrame(ctx, bytes, read, custom.ResponseLength)
+		if err != nil {
+			t.logger.Warn("Failed to read body bytes", zap.Error(err))
+			return nil, err
+		}
 	}
 
 	t.logger.Debug("Raw Frame", zap.String("bytes", common.EncodeToString(bytes[:read])))
@@ -230,6 +446,31 @@ func (t *modbusRTUTransport) ReadResponse(ctx context.Context, request transport
 	return ParseModbusResponseFrame(bytes[:read], 0)
 }
 
+// readCustomFrame reads the rest of a custom function's frame, asking the function how long its data is until it knows
+func (t *modbusRTUTransport) readCustomFrame(ctx context.Context, bytes []byte, read int, frameLength data.FrameLengthFunc) (int, error) {
+	for {
+		// The data starts after the address and function code
+		length, ok := frameLength(bytes[2:read])
+		bytesNeeded := length + 2
+		if ok {
+			// Add 2 more for the CRC
+			bytesNeeded += 2
+		}
+		if bytesNeeded > len(bytes) || bytesNeeded <= read {
+			t.logger.Warn("Custom function has an invalid length, this is likely a corrupt packet", zap.Int("bytesNeeded", bytesNeeded), zap.String("bytes", common.EncodeToString(bytes[:read])))
+			return 0, common.ErrInvalidPacket
+		}
+		var err error
+		read, err = t.readWithTimeout(ctx, t.responseTimeout, bytes[read:bytesNeeded], read)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return read, nil
+		}
+	}
+}
+
 func (t *modbusRTUTransport) WriteRequestFrame(address uint16, pdu *transport.ProtocolDataUnit) (transport.ApplicationDataUnit, error) {
 	header := serial.NewHeader(address)
 	adu, err := t.frameBuilder.BuildResponseFrame(header, pdu)
@@ -265,16 +506,22 @@ func (t *modbusRTUTransport) write(p []byte) (int, error) {
 	return n, nil
 }
 
+// flushGap is how long the line has to be quiet before the next byte is taken as the start of a frame
+const flushGap = 20 * time.Millisecond
+
 func (t *modbusRTUTransport) Flush(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if t.client {
+		return t.flushResponse(ctx)
+	}
 	timeoutStart := time.Now()
 	flushedByteCount := 0
 	for {
 		start := time.Now()
 		_, _ = t.reader.ReadByte()
 		readTime := time.Since(start)
-		if readTime > 20*time.Millisecond {
+		if readTime > flushGap {
 			t.reader.UnreadByte()
 			t.logger.Debug("Flushed", zap.Int("bytesFlushed", flushedByteCount))
 			return nil
@@ -287,6 +534,27 @@ func (t *modbusRTUTransport) Flush(ctx context.Context) error {
 	}
 }
 
+// flushResponse drops what is left of a response the client gave up on. Unlike a server, which waits for the next frame
+// to start, a client has nothing coming once the line goes quiet, so it stops there.
+func (t *modbusRTUTransport) flushResponse(ctx context.Context) error {
+	deadline := time.Now().Add(t.responseTimeout)
+	flushedByteCount := 0
+	b := make([]byte, 1)
+	for time.Now().Before(deadline) {
+		if _, err := t.readWithTimeout(ctx, flushGap, b, 0); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Either we or the port gave up waiting, so there is nothing left to drop
+			t.logger.Debug("Flushed", zap.Int("bytesFlushed", flushedByteCount))
+			return nil
+		}
+		flushedByteCount++
+	}
+	t.logger.Error("Line didn't go quiet while flushing")
+	return common.ErrTimeout
+}
+
 func (t *modbusRTUTransport) Close() error {
 	defer t.wg.Wait()
 	t.closing = true
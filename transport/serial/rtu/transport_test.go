@@ -63,10 +63,40 @@ func TestReadRequest(t *testing.T) {
 	assert.NotNil(t, txn)
 	assert.Equal(t, uint16(0x04), txn.Header().(transport.SerialHeader).Address())
 	assert.Equal(t, data.ReadCoils, txn.PDU().FunctionCode())
-	assert.Equal(t, []byte{0x00, 0x0A, 0x00, 0x0D}, data.ModbusOperationToBytes(txn.PDU().Operation()))
+	opBytes, err := data.ModbusOperationToBytes(txn.PDU().Operation())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x0A, 0x00, 0x0D}, opBytes)
 	assert.Equal(t, []byte{0xDD, 0x98}, []byte(txn.Checksum()))
 }
 
+func TestReadRequest_FileRecordOversizedByteCount(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	// A Read File Record header claiming 0xFF bytes is discarded and the next frame is read
+	port := newTestSerialPort([]byte{0x04, 0x14, 0xFF, 0x04, 0x01, 0x00, 0x0A, 0x00, 0x0D, 0xDD, 0x98})
+	tp := NewModbusServerTransport(port, logger, 0x04)
+	defer tp.Close()
+	txn, err := tp.ReadRequest(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, data.ReadCoils, txn.PDU().FunctionCode())
+	}
+}
+
+func TestReadRequest_ReadWriteMultipleRegistersOversizedWriteCount(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	// A Read/Write Multiple Registers header writing 127 registers is discarded and the next frame is read
+	port := newTestSerialPort([]byte{0x04, 0x17, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x7F, 0xFE, 0x04, 0x01, 0x00, 0x0A, 0x00, 0x0D, 0xDD, 0x98})
+	tp := NewModbusServerTransport(port, logger, 0x04)
+	defer tp.Close()
+	txn, err := tp.ReadRequest(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, data.ReadCoils, txn.PDU().FunctionCode())
+	}
+}
+
 func TestReadCoils(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	tests := []struct {
@@ -395,6 +425,49 @@ func TestWriteMultipleRegisters(t *testing.T) {
 	}
 }
 
+func TestReadWriteMultipleRegisters(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tests := []struct {
+		name      string
+		request   []byte
+		readError error
+	}{
+		{
+			name:    "Valid",
+			request: []byte{0x04, 0x17, 0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x55, 0xC0},
+		},
+		{
+			name:      "InvalidRequest_InvalidChecksum",
+			request:   []byte{0x04, 0x17, 0x00, 0x03, 0x00, 0x06, 0x00, 0x0E, 0x00, 0x03, 0x06, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x55, 0xC1},
+			readError: common.ErrInvalidChecksum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			port := newTestSerialPort([]byte(tt.request))
+			tp := NewModbusServerTransport(port, logger, 0x04)
+			defer tp.Close()
+			txn, err := tp.ReadRequest(ctx)
+			if tt.readError != nil {
+				assert.Error(t, err)
+				assert.Nil(t, txn)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, txn)
+			assert.Equal(t, uint16(0x04), txn.Header().(transport.SerialHeader).Address())
+			assert.Equal(t, data.ReadWriteMultipleRegisters, txn.PDU().FunctionCode())
+			assert.Equal(t, uint16(0x03), txn.PDU().Operation().(*data.ReadWriteMultipleRegistersRequest).ReadOffset())
+			assert.Equal(t, 6, txn.PDU().Operation().(*data.ReadWriteMultipleRegistersRequest).Count())
+			assert.Equal(t, uint16(0x0E), txn.PDU().Operation().(*data.ReadWriteMultipleRegistersRequest).WriteOffset())
+			assert.Equal(t, []uint16{0x00FF, 0x00FF, 0x00FF}, txn.PDU().Operation().(*data.ReadWriteMultipleRegistersRequest).Values())
+			assert.Equal(t, transport.ErrorCheck([]byte{0x55, 0xC0}), txn.Checksum())
+		})
+	}
+}
+
 func TestReadCoils_DisjoinedReads(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	tests := []struct {
@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"time"
 
 	"github.com/rinzlerlabs/gomodbus/common"
 )
@@ -15,6 +16,30 @@ type Transport interface {
 	Close() error
 }
 
+// PipelinedTransport is implemented by transports that match responses to their requests, so more than one request can
+// be outstanding at a time.
+type PipelinedTransport interface {
+	Transport
+	// MaxInFlight returns the number of requests that can be outstanding at once.
+	MaxInFlight() int
+}
+
+// BroadcastTransport is implemented by transports where a request to address 0 is carried out by every device and
+// answered by none.
+type BroadcastTransport interface {
+	Transport
+	// TurnaroundDelay returns how long to wait after a broadcast before sending the next request, giving the devices time
+	// to carry it out.
+	TurnaroundDelay() time.Duration
+}
+
+// FilteringTransport is implemented by server transports that skip the frames sent to devices they don't serve.
+type FilteringTransport interface {
+	Transport
+	// SetAddressFilter sets the addresses whose frames are read, accepts is called with the address of every frame.
+	SetAddressFilter(accepts func(address uint16) bool)
+}
+
 type NilTransport struct{}
 
 func (t *NilTransport) Flush(context.Context) error {